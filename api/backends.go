@@ -2,6 +2,9 @@ package api
 
 import (
 	"net/http"
+	"sort"
+	"sync"
+	"time"
 
 	"os"
 
@@ -9,13 +12,26 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/underarmour/libra/backend"
 	"github.com/underarmour/libra/config"
+	"github.com/underarmour/libra/structs"
 )
 
 type BackendResponse struct {
-	Name string `json:"name"`
-	Kind string `json:"kind"`
+	Name    string                `json:"name"`
+	Kind    string                `json:"kind"`
+	Breaker backend.BreakerStatus `json:"breaker"`
+	Health  *BackendHealth        `json:"health,omitempty"`
 }
 
+// BackendHealth is the result of a health check. Status is one of ok, error
+// or unsupported.
+type BackendHealth struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// BackendsHandler lists the configured backends. With ?test=true every
+// backend is health checked as well.
 func BackendsHandler(w rest.ResponseWriter, r *rest.Request) {
 	config, err := config.NewConfig(os.Getenv("LIBRA_CONFIG_DIR"))
 	if err != nil {
@@ -30,19 +46,46 @@ func BackendsHandler(w rest.ResponseWriter, r *rest.Request) {
 		rest.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	backendResponses := []BackendResponse{}
+	test := r.URL.Query().Get("test") == "true"
+
+	backendResponses := make([]BackendResponse, len(backends))
+	var wg sync.WaitGroup
+	i := 0
 	for _, bv := range backends {
-		newBV := BackendResponse{
-			Name: bv.Info().Name,
-			Kind: bv.Info().Kind,
+		backendResponses[i] = BackendResponse{
+			Name:    bv.Info().Name,
+			Kind:    bv.Info().Kind,
+			Breaker: backend.Breaker(bv.Info().Name),
 		}
-		backendResponses = append(backendResponses, newBV)
+		if test {
+			wg.Add(1)
+			go func(bv structs.Backender, resp *BackendResponse) {
+				defer wg.Done()
+				resp.Health = checkBackend(bv)
+			}(bv, &backendResponses[i])
+		}
+		i++
 	}
+	wg.Wait()
+	sort.Slice(backendResponses, func(i, j int) bool {
+		return backendResponses[i].Name < backendResponses[j].Name
+	})
 
-	if err != nil {
-		log.Errorf("Problem getting backends: %s", err.Error())
-		rest.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
 	w.WriteJson(backendResponses)
 }
+
+func checkBackend(bv structs.Backender) *BackendHealth {
+	latency, err := backend.HealthCheck(bv)
+	health := &BackendHealth{
+		Status:    "ok",
+		LatencyMS: float64(latency) / float64(time.Millisecond),
+	}
+	if err == backend.ErrHealthCheckUnsupported {
+		health.Status = "unsupported"
+	} else if err != nil {
+		log.Warnf("Health check of backend %s failed: %s", bv.Info().Name, err)
+		health.Status = "error"
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/ant0ine/go-json-rest/rest"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/backend"
+	"github.com/underarmour/libra/config"
+)
+
+type GroupResponse struct {
+	Job                  string                 `json:"job"`
+	Group                string                 `json:"group"`
+	Policy               string                 `json:"policy"`
+	MinCount             int                    `json:"min_count"`
+	MaxCount             int                    `json:"max_count"`
+	ScaleOutCooldown     string                 `json:"scale_out_cooldown,omitempty"`
+	ScaleInCooldown      string                 `json:"scale_in_cooldown,omitempty"`
+	ScaleInStabilization string                 `json:"scale_in_stabilization,omitempty"`
+	Cooldown             backend.CooldownStatus `json:"cooldown"`
+}
+
+// GroupsHandler lists the configured groups with their cooldown state
+func GroupsHandler(w rest.ResponseWriter, r *rest.Request) {
+	config, err := config.NewConfig(os.Getenv("LIBRA_CONFIG_DIR"))
+	if err != nil {
+		log.Errorf("Failed to read or parse config file: %s", err)
+		rest.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Info("Loaded and parsed configuration file")
+
+	groupResponses := []GroupResponse{}
+	for _, job := range config.Jobs {
+		for _, group := range job.Groups {
+			policy := group.Policy
+			if policy == "" {
+				policy = backend.PolicyThreshold
+			}
+			groupResponses = append(groupResponses, GroupResponse{
+				Job:                  job.Name,
+				Group:                group.Name,
+				Policy:               policy,
+				MinCount:             group.MinCount,
+				MaxCount:             group.MaxCount,
+				ScaleOutCooldown:     group.ScaleOutCooldown,
+				ScaleInCooldown:      group.ScaleInCooldown,
+				ScaleInStabilization: group.ScaleInStabilization,
+				Cooldown:             backend.GroupCooldown(job.Name, group.Name),
+			})
+		}
+	}
+	sort.Slice(groupResponses, func(i, j int) bool {
+		if groupResponses[i].Job != groupResponses[j].Job {
+			return groupResponses[i].Job < groupResponses[j].Job
+		}
+		return groupResponses[i].Group < groupResponses[j].Group
+	})
+
+	w.WriteJson(groupResponses)
+}
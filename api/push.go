@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ant0ine/go-json-rest/rest"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/backend"
+)
+
+// PushMetric is a single value for a push backend. Timestamp is in Unix
+// seconds and defaults to the time the request is received.
+type PushMetric struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+type PushRequest struct {
+	Metrics []PushMetric `json:"metrics"`
+}
+
+type PushResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+func PushHandler(w rest.ResponseWriter, r *rest.Request) {
+	var t PushRequest
+	err := r.DecodeJsonPayload(&t)
+	if err != nil {
+		log.Errorln(err)
+		rest.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if len(t.Metrics) == 0 {
+		rest.Error(w, "no metrics in request", http.StatusBadRequest)
+		return
+	}
+	for _, m := range t.Metrics {
+		if m.Name == "" {
+			rest.Error(w, "every metric needs a name", http.StatusBadRequest)
+			return
+		}
+		if err := backend.ValidatePushTimestamp(time.Unix(m.Timestamp, 0)); err != nil {
+			rest.Error(w, fmt.Sprintf("metric %s: %s", m.Name, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	accepted := 0
+	for _, m := range t.Metrics {
+		var timestamp time.Time
+		if m.Timestamp != 0 {
+			timestamp = time.Unix(m.Timestamp, 0)
+		}
+		if err := backend.Push(m.Name, m.Value, timestamp); err != nil {
+			log.Errorf("Problem storing pushed metric: %s", err)
+			rest.Error(w, fmt.Sprintf("%s (accepted %d of %d metrics)", err, accepted, len(t.Metrics)), http.StatusInsufficientStorage)
+			return
+		}
+		accepted++
+	}
+
+	w.WriteJson(&PushResponse{
+		Accepted: accepted,
+	})
+}
@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// evaluationHistory holds the outcome of the latest evaluations of every
+// rule, oldest first, keyed by job/group/rule. An outcome is the direction
+// the rule wanted to scale in, 1 or -1, or 0 when its condition did not hold.
+var evaluationHistory = struct {
+	sync.Mutex
+	byRule map[string][]int
+}{byRule: map[string][]int{}}
+
+// ValidateEvaluationPeriods checks the evaluation_periods and
+// datapoints_to_alarm of a rule
+func ValidateEvaluationPeriods(r *structs.Rule) error {
+	if r.EvaluationPeriods < 0 || r.DatapointsToAlarm < 0 {
+		return fmt.Errorf("evaluation_periods and datapoints_to_alarm cannot be negative")
+	}
+	if r.DatapointsToAlarm > evaluationPeriods(r) {
+		return fmt.Errorf("datapoints_to_alarm (%d) cannot be more than evaluation_periods (%d)", r.DatapointsToAlarm, evaluationPeriods(r))
+	}
+	return nil
+}
+
+func evaluationPeriods(r *structs.Rule) int {
+	if r.EvaluationPeriods < 1 {
+		return 1
+	}
+	return r.EvaluationPeriods
+}
+
+// alarming records the direction the rule wants to scale in this evaluation,
+// or 0 when its condition did not hold. It reports whether the rule wanted
+// that same direction in datapoints_to_alarm (default evaluation_periods) of
+// the last evaluation_periods (default 1) evaluations.
+func alarming(r *structs.Rule, job, group string, direction int) bool {
+	periods := evaluationPeriods(r)
+	needed := r.DatapointsToAlarm
+	if needed < 1 {
+		needed = periods
+	}
+
+	evaluationHistory.Lock()
+	defer evaluationHistory.Unlock()
+	key := job + "/" + group + "/" + r.Name
+	history := append(evaluationHistory.byRule[key], direction)
+	if len(history) > periods {
+		history = history[len(history)-periods:]
+	}
+	evaluationHistory.byRule[key] = history
+
+	if direction == 0 {
+		return false
+	}
+	count := 0
+	for _, d := range history {
+		if d == direction {
+			count++
+		}
+	}
+	if count < needed {
+		log.Infof("Rule %s breached in %d of the last %d evaluations, waiting for %d", r.Name, count, len(history), needed)
+	}
+	return count >= needed
+}
@@ -0,0 +1,22 @@
+package backend
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// newAWSSession creates the session shared by the AWS backends. endpoint is
+// optional and overrides the service endpoint, e.g. for a local stand-in.
+// Requests are bounded by defaultTimeout until setClientTimeout changes it.
+func newAWSSession(region, endpoint string) *session.Session {
+	awsConfig := &aws.Config{
+		Region:     aws.String(region),
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+	}
+	if endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+	return session.Must(session.NewSession(awsConfig))
+}
@@ -3,6 +3,8 @@ package backend
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -35,9 +37,10 @@ func InitializeBackends(backends map[string]structs.Backend) (ConfiguredBackends
 			conf := c.Backends[name]
 
 			connection, err := NewCloudWatchBackend(name, CloudWatchConfig{
-				Kind:   conf.Kind,
-				Name:   conf.Name,
-				Region: conf.Region,
+				Kind:     conf.Kind,
+				Name:     conf.Name,
+				Region:   conf.Region,
+				Endpoint: conf.Endpoint,
 			})
 			if err != nil {
 				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
@@ -71,10 +74,292 @@ func InitializeBackends(backends map[string]structs.Backend) (ConfiguredBackends
 
 			configuredBackends[name] = connection
 
+		case "prometheus":
+			password := backend.Password
+			if password == "" {
+				password = os.Getenv("PROMETHEUS_PASSWORD")
+			}
+			token := backend.BearerToken
+			if token == "" {
+				token = os.Getenv("PROMETHEUS_BEARER_TOKEN")
+			}
+			connection, err := NewPrometheusBackend(name, PrometheusConfig{
+				Kind:        backend.Kind,
+				Name:        backend.Name,
+				Host:        backend.Host,
+				Username:    backend.Username,
+				Password:    password,
+				BearerToken: token,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "nomad":
+			address := backend.Host
+			if address == "" {
+				c, err := config.NewConfig(os.Getenv("LIBRA_CONFIG_DIR"))
+				if err != nil {
+					log.Errorf("Failed to read or parse config file: %s", err)
+					return nil, err
+				}
+				address = c.Nomad.Address
+			}
+			connection, err := NewNomadBackend(name, NomadConfig{
+				Kind:    backend.Kind,
+				Name:    backend.Name,
+				Address: address,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "influxdb":
+			password := backend.Password
+			if password == "" {
+				password = os.Getenv("INFLUXDB_PASSWORD")
+			}
+			token := backend.BearerToken
+			if token == "" {
+				token = os.Getenv("INFLUXDB_TOKEN")
+			}
+			connection, err := NewInfluxDBBackend(name, InfluxDBConfig{
+				Kind:     backend.Kind,
+				Name:     backend.Name,
+				Host:     backend.Host,
+				Username: backend.Username,
+				Password: password,
+				Token:    token,
+				Database: backend.Database,
+				Org:      backend.Org,
+				Bucket:   backend.Bucket,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "http":
+			connection, err := NewHTTPBackend(name, HTTPConfig{
+				Kind:     backend.Kind,
+				Name:     backend.Name,
+				Host:     backend.Host,
+				Username: backend.Username,
+				Password: backend.Password,
+				Headers:  backend.Headers,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "exec":
+			connection, err := NewExecBackend(name, ExecConfig{
+				Kind: backend.Kind,
+				Name: backend.Name,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "sqs":
+			c, err := config.NewConfig(os.Getenv("LIBRA_CONFIG_DIR"))
+			if err != nil {
+				log.Errorf("Failed to read or parse config file: %s", err)
+				return nil, err
+			}
+
+			connection, err := NewSQSBackend(name, SQSConfig{
+				Kind:         backend.Kind,
+				Name:         backend.Name,
+				Region:       backend.Region,
+				Endpoint:     backend.Endpoint,
+				NomadAddress: c.Nomad.Address,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "redis":
+			password := backend.Password
+			if password == "" {
+				password = os.Getenv("REDIS_PASSWORD")
+			}
+			connection, err := NewRedisBackend(name, RedisConfig{
+				Kind:          backend.Kind,
+				Name:          backend.Name,
+				Host:          backend.Host,
+				Username:      backend.Username,
+				Password:      password,
+				DB:            backend.DB,
+				TLS:           backend.TLS,
+				TLSSkipVerify: backend.TLSSkipVerify,
+				CACert:        backend.CACert,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "kafka":
+			brokers := []string{}
+			for _, broker := range strings.Split(backend.Host, ",") {
+				if broker = strings.TrimSpace(broker); broker != "" {
+					brokers = append(brokers, broker)
+				}
+			}
+			connection, err := NewKafkaBackend(name, KafkaConfig{
+				Kind:          backend.Kind,
+				Name:          backend.Name,
+				Brokers:       brokers,
+				TLS:           backend.TLS,
+				TLSSkipVerify: backend.TLSSkipVerify,
+				CACert:        backend.CACert,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "elasticsearch":
+			password := backend.Password
+			if password == "" {
+				password = os.Getenv("ELASTICSEARCH_PASSWORD")
+			}
+			connection, err := NewElasticsearchBackend(name, ElasticsearchConfig{
+				Kind:     backend.Kind,
+				Name:     backend.Name,
+				Host:     backend.Host,
+				Username: backend.Username,
+				Password: password,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "consul":
+			connection, err := NewConsulBackend(name, ConsulConfig{
+				Kind:          backend.Kind,
+				Name:          backend.Name,
+				Host:          backend.Host,
+				Datacenter:    backend.Datacenter,
+				Token:         backend.Token,
+				TLSSkipVerify: backend.TLSSkipVerify,
+				CACert:        backend.CACert,
+				ClientCert:    backend.ClientCert,
+				ClientKey:     backend.ClientKey,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "datadog":
+			apiKey := backend.APIKey
+			if apiKey == "" {
+				apiKey = os.Getenv("DATADOG_API_KEY")
+			}
+			appKey := backend.AppKey
+			if appKey == "" {
+				appKey = os.Getenv("DATADOG_APP_KEY")
+			}
+			connection, err := NewDatadogBackend(name, DatadogConfig{
+				Kind:   backend.Kind,
+				Name:   backend.Name,
+				Host:   backend.Host,
+				APIKey: apiKey,
+				AppKey: appKey,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "push":
+			connection, err := NewPushBackend(name, PushConfig{
+				Kind: backend.Kind,
+				Name: backend.Name,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "expression":
+			connection, err := NewExpressionBackend(name, ExpressionConfig{
+				Kind: backend.Kind,
+				Name: backend.Name,
+			}, configuredBackends)
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "static":
+			connection, err := NewStaticBackend(name, StaticConfig{
+				Kind:   backend.Kind,
+				Name:   backend.Name,
+				Value:  backend.Value,
+				Values: backend.Values,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
+		case "replay":
+			connection, err := NewReplayBackend(name, ReplayConfig{
+				Kind: backend.Kind,
+				Name: backend.Name,
+				Path: backend.Path,
+				Loop: backend.Loop,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+			}
+
+			configuredBackends[name] = connection
+
 		default:
 			log.Fatalf("unknown backend type '%s' for backend %s", backendType, name)
 			return nil, fmt.Errorf("unknown backend %s", backendType)
 		}
+
+		guard, err := NewGuardConfig(backend)
+		if err != nil {
+			return nil, fmt.Errorf("Bad configuration for %s: %s", name, err)
+		}
+		if backend.Timeout != "" {
+			setClientTimeout(configuredBackends[name], guard.Timeout)
+		}
+		configuredBackends[name] = NewGuardedBackend(name, guard, configuredBackends[name])
+
+		if backend.CacheTTL != "" {
+			ttl, err := time.ParseDuration(backend.CacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("Bad configuration for %s: bad cache_ttl '%s': %s", name, backend.CacheTTL, err)
+			}
+			configuredBackends[name] = NewCachedBackend(name, ttl, configuredBackends[name])
+		}
 	}
 
 	return configuredBackends, nil
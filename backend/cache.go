@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+	"github.com/underarmour/libra/structs"
+)
+
+// CachedBackend caches the values of another backend for TTL, and makes
+// rules that ask for the same value at the same time share one request
+type CachedBackend struct {
+	Name    string
+	TTL     time.Duration
+	Backend structs.Backender
+}
+
+// NewCachedBackend will wrap backend with a cache
+func NewCachedBackend(name string, ttl time.Duration, backend structs.Backender) *CachedBackend {
+	return &CachedBackend{
+		Name:    name,
+		TTL:     ttl,
+		Backend: backend,
+	}
+}
+
+// GetValue returns the cached value for the rule's query parameters, or gets
+// it from the wrapped backend. Errors are not cached.
+func (b *CachedBackend) GetValue(rule structs.Rule) (float64, error) {
+	var params interface{} = queryParameters(rule)
+	if keyer, ok := unwrap(b.Backend).(cacheKeyer); ok {
+		var err error
+		params, err = keyer.cacheKey(rule)
+		if err != nil {
+			return b.Backend.GetValue(rule)
+		}
+	}
+	hash, err := hashstructure.Hash(params, nil)
+	if err != nil {
+		return b.Backend.GetValue(rule)
+	}
+	key := fmt.Sprintf("%s/%d", b.Name, hash)
+	return responseCache.get(key, b.TTL, func() (float64, error) {
+		return b.Backend.GetValue(rule)
+	})
+}
+
+func (b *CachedBackend) Info() *structs.Backend {
+	return b.Backend.Info()
+}
+
+// Unwrap returns the wrapped backend
+func (b *CachedBackend) Unwrap() structs.Backender {
+	return b.Backend
+}
+
+// cacheKeyer is implemented by backends whose query depends on more of a
+// rule than queryParameters keeps, such as its name. cacheKey returns what
+// identifies the query instead.
+type cacheKeyer interface {
+	cacheKey(rule structs.Rule) (interface{}, error)
+}
+
+// queryParameters clears the fields of a rule that only matter once the
+// value is known, so rules asking the same question share a cache key. Job
+// and group are cleared too, since most backends do not use them.
+func queryParameters(rule structs.Rule) structs.Rule {
+	rule.Name = ""
+	rule.Job = ""
+	rule.Group = ""
+	rule.BackendInstance = nil
+	rule.Comparison = ""
+	rule.ComparisonValue = 0
+	rule.TargetValue = 0
+	rule.Action = ""
+	rule.ActionValue = 0
+	rule.Steps = nil
+	rule.EvaluationPeriods = 0
+	rule.DatapointsToAlarm = 0
+	rule.Period = ""
+	return rule
+}
+
+// groupCacheKey keeps the job and group of a rule in its cache key, for
+// backends whose value depends on them
+func groupCacheKey(rule structs.Rule) interface{} {
+	return []interface{}{rule.Job, rule.Group, queryParameters(rule)}
+}
+
+type cacheEntry struct {
+	value   float64
+	err     error
+	expires time.Time
+	loading bool
+	done    chan struct{}
+}
+
+// backendCache holds values by backend and query parameters. It is shared by
+// every set of configured backends, so it outlives a reload of the backends.
+type backendCache struct {
+	sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+var responseCache = &backendCache{entries: map[string]*cacheEntry{}}
+
+// get returns the value for key, calling fetch when it is missing or has
+// expired. Callers that ask while fetch is running wait for its result.
+func (c *backendCache) get(key string, ttl time.Duration, fetch func() (float64, error)) (float64, error) {
+	c.Lock()
+	if e, ok := c.entries[key]; ok {
+		if e.loading {
+			c.Unlock()
+			<-e.done
+			return e.value, e.err
+		}
+		if time.Now().Before(e.expires) {
+			c.Unlock()
+			return e.value, nil
+		}
+	}
+	c.removeExpired()
+	e := &cacheEntry{loading: true, done: make(chan struct{})}
+	c.entries[key] = e
+	c.Unlock()
+
+	value, err := fetch()
+
+	c.Lock()
+	e.value = value
+	e.err = err
+	e.expires = time.Now().Add(ttl)
+	e.loading = false
+	if err != nil {
+		delete(c.entries, key)
+	}
+	close(e.done)
+	c.Unlock()
+
+	return value, err
+}
+
+// removeExpired drops expired entries. The lock must be held.
+func (c *backendCache) removeExpired() {
+	now := time.Now()
+	for key, e := range c.entries {
+		if !e.loading && !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// unwrap returns the backend inside wrappers such as CachedBackend and
+// GuardedBackend
+func unwrap(backend structs.Backender) structs.Backender {
+	for {
+		w, ok := backend.(interface {
+			Unwrap() structs.Backender
+		})
+		if !ok {
+			return backend
+		}
+		backend = w.Unwrap()
+	}
+}
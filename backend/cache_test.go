@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+	"github.com/underarmour/libra/structs"
+)
+
+// countingBackend counts how often it is asked for a value
+type countingBackend struct {
+	calls int
+}
+
+func (b *countingBackend) GetValue(rule structs.Rule) (float64, error) {
+	b.calls++
+	return float64(b.calls), nil
+}
+
+func (b *countingBackend) Info() *structs.Backend {
+	return &structs.Backend{Name: "counting"}
+}
+
+func TestCacheSharesQueriesAcrossGroups(t *testing.T) {
+	counting := &countingBackend{}
+	b := NewCachedBackend("test cache groups", time.Minute, counting)
+	rules := []structs.Rule{
+		{Name: "upper", Job: "api", Group: "web", MetricName: "cpu", Comparison: "above", ComparisonValue: 80},
+		{Name: "lower", Job: "api", Group: "web", MetricName: "cpu", Comparison: "below", ComparisonValue: 20},
+		{Name: "upper", Job: "worker", Group: "batch", MetricName: "cpu", Comparison: "above", ComparisonValue: 90},
+	}
+	for _, r := range rules {
+		if _, err := b.GetValue(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counting.calls != 1 {
+		t.Errorf("expected one query for the same metric, got %d", counting.calls)
+	}
+
+	if _, err := b.GetValue(structs.Rule{Name: "upper", MetricName: "memory"}); err != nil {
+		t.Fatal(err)
+	}
+	if counting.calls != 2 {
+		t.Errorf("expected another query for a different metric, got %d", counting.calls)
+	}
+}
+
+func TestCacheKeepsGroupsOfNomadRules(t *testing.T) {
+	nomad := &NomadBackend{Name: "nomad"}
+	web, err := nomad.cacheKey(structs.Rule{Job: "api", Group: "web", MetricName: "cpu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := nomad.cacheKey(structs.Rule{Job: "api", Group: "batch", MetricName: "cpu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webHash, _ := hashstructure.Hash(web, nil)
+	batchHash, _ := hashstructure.Hash(batch, nil)
+	if webHash == batchHash {
+		t.Error("expected rules of different groups to have different cache keys")
+	}
+}
@@ -2,12 +2,14 @@ package backend
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"errors"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	log "github.com/sirupsen/logrus"
 	"github.com/underarmour/libra/structs"
@@ -15,9 +17,10 @@ import (
 
 // CloudWatchConfig is the configuration for a CloudWatch backend
 type CloudWatchConfig struct {
-	Name   string
-	Kind   string
-	Region string
+	Name     string
+	Kind     string
+	Region   string
+	Endpoint string
 }
 
 // CloudWatchBackend is a metrics backend
@@ -30,9 +33,7 @@ type CloudWatchBackend struct {
 // NewCloudWatchBackend will create a new CloudWatch Client
 func NewCloudWatchBackend(name string, config CloudWatchConfig) (*CloudWatchBackend, error) {
 	// create the cloudwatch client
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region: aws.String(config.Region),
-	}))
+	sess := newAWSSession(config.Region, config.Endpoint)
 	svc := cloudwatch.New(sess)
 
 	backend := &CloudWatchBackend{}
@@ -43,8 +44,13 @@ func NewCloudWatchBackend(name string, config CloudWatchConfig) (*CloudWatchBack
 	return backend, nil
 }
 
-// GetValue gets a value
+// GetValue gets the newest datapoint of a metric statistic, or of a metric
+// math expression when the rule declares metric{} stanzas
 func (b *CloudWatchBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.Expression != "" || len(rule.Metrics) > 0 {
+		return b.getMetricDataValue(rule)
+	}
+
 	metricName := rule.MetricName
 	if metricName == "" {
 		return 0.0, fmt.Errorf("Missing metric_name inside config{} stanza")
@@ -55,28 +61,42 @@ func (b *CloudWatchBackend) GetValue(rule structs.Rule) (float64, error) {
 		return 0.0, fmt.Errorf("Missing metric_namespace inside config{} stanza for rule %s", rule.Name)
 	}
 
-	dimensionName := rule.DimensionName
-	if dimensionName == "" {
-		return 0.0, fmt.Errorf("Missing dimension_name inside config{} stanza")
+	dimensions, err := cloudWatchDimensions(rule)
+	if err != nil {
+		return 0.0, err
+	}
+
+	period := int64(rule.MetricPeriod)
+	if period == 0 {
+		period = 300
 	}
 
-	dimensionValue := rule.DimensionValue
-	if dimensionValue == "" {
-		return 0.0, fmt.Errorf("Missing dimension_value inside config{} stanza")
+	lookback := 3 * time.Hour
+	if rule.Lookback != "" {
+		lookback, err = time.ParseDuration(rule.Lookback)
+		if err != nil {
+			return 0.0, fmt.Errorf("Bad lookback '%s' for rule %s: %s", rule.Lookback, rule.Name, err)
+		}
 	}
 
-	dimension := &cloudwatch.Dimension{
-		Name:  aws.String(dimensionName),
-		Value: aws.String(dimensionValue),
+	statistic := rule.Statistic
+	if statistic == "" {
+		statistic = "Average"
 	}
+
+	now := time.Now()
 	dinput := &cloudwatch.GetMetricStatisticsInput{
-		Dimensions: []*cloudwatch.Dimension{dimension},
-		EndTime:    aws.Time(time.Now()),
+		Dimensions: dimensions,
+		EndTime:    aws.Time(now),
 		MetricName: aws.String(metricName),
 		Namespace:  aws.String(metricNamespace),
-		Period:     aws.Int64(300),
-		StartTime:  aws.Time(time.Now().Add(-3 * time.Hour)),
-		Statistics: aws.StringSlice([]string{"Average"}),
+		Period:     aws.Int64(period),
+		StartTime:  aws.Time(now.Add(-lookback)),
+	}
+	if isExtendedStatistic(statistic) {
+		dinput.ExtendedStatistics = aws.StringSlice([]string{statistic})
+	} else {
+		dinput.Statistics = aws.StringSlice([]string{statistic})
 	}
 
 	s, err := b.Connection.GetMetricStatistics(dinput)
@@ -87,7 +107,12 @@ func (b *CloudWatchBackend) GetValue(rule structs.Rule) (float64, error) {
 	if len(s.Datapoints) == 0 {
 		return 0.0, errors.New("no datapoints found for metric")
 	}
-	return *s.Datapoints[len(s.Datapoints)-1].Average, nil
+
+	// CloudWatch does not return datapoints in any particular order
+	sort.Slice(s.Datapoints, func(i, j int) bool {
+		return s.Datapoints[i].Timestamp.Before(*s.Datapoints[j].Timestamp)
+	})
+	return datapointValue(s.Datapoints[len(s.Datapoints)-1], statistic)
 }
 
 func (b *CloudWatchBackend) Info() *structs.Backend {
@@ -96,3 +121,73 @@ func (b *CloudWatchBackend) Info() *structs.Backend {
 		Name: b.Name,
 	}
 }
+
+// HealthCheck lists CloudWatch metrics, which checks the credentials and region
+func (b *CloudWatchBackend) HealthCheck() error {
+	_, err := b.Connection.ListMetrics(&cloudwatch.ListMetricsInput{})
+	return err
+}
+
+// cloudWatchDimensions merges the dimensions map and the single
+// dimension_name/dimension_value pair of a rule
+func cloudWatchDimensions(rule structs.Rule) ([]*cloudwatch.Dimension, error) {
+	var dimensions []*cloudwatch.Dimension
+	if rule.DimensionName != "" || rule.DimensionValue != "" {
+		if rule.DimensionName == "" {
+			return nil, fmt.Errorf("Missing dimension_name inside config{} stanza")
+		}
+		if rule.DimensionValue == "" {
+			return nil, fmt.Errorf("Missing dimension_value inside config{} stanza")
+		}
+		dimensions = append(dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(rule.DimensionName),
+			Value: aws.String(rule.DimensionValue),
+		})
+	}
+
+	names := make([]string, 0, len(rule.Dimensions))
+	for name := range rule.Dimensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dimensions = append(dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(name),
+			Value: aws.String(rule.Dimensions[name]),
+		})
+	}
+	return dimensions, nil
+}
+
+// isExtendedStatistic reports whether statistic is a percentile such as p99
+// or p99.9, which CloudWatch only accepts as an extended statistic
+func isExtendedStatistic(statistic string) bool {
+	if !strings.HasPrefix(statistic, "p") {
+		return false
+	}
+	_, err := strconv.ParseFloat(statistic[1:], 64)
+	return err == nil
+}
+
+// datapointValue returns the value of the requested statistic from a datapoint
+func datapointValue(d *cloudwatch.Datapoint, statistic string) (float64, error) {
+	var value *float64
+	switch statistic {
+	case "Average":
+		value = d.Average
+	case "Sum":
+		value = d.Sum
+	case "Maximum":
+		value = d.Maximum
+	case "Minimum":
+		value = d.Minimum
+	case "SampleCount":
+		value = d.SampleCount
+	default:
+		value = d.ExtendedStatistics[statistic]
+	}
+	if value == nil {
+		return 0.0, fmt.Errorf("datapoint has no value for statistic %s", statistic)
+	}
+	return *value, nil
+}
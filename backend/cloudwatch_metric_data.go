@@ -0,0 +1,239 @@
+package backend
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// The vendored SDK predates GetMetricData, so the operation and its shapes are
+// declared here and sent through the CloudWatch client's query protocol
+// handlers. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_GetMetricData.html
+
+const opGetMetricData = "GetMetricData"
+
+// expressionQueryID is the id of the query holding a rule's math expression
+const expressionQueryID = "libra_expression"
+
+var metricDataQueryID = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+type getMetricDataInput struct {
+	_ struct{} `type:"structure"`
+
+	EndTime           *time.Time         `type:"timestamp" timestampFormat:"iso8601"`
+	MetricDataQueries []*metricDataQuery `type:"list"`
+	NextToken         *string            `type:"string"`
+	ScanBy            *string            `type:"string"`
+	StartTime         *time.Time         `type:"timestamp" timestampFormat:"iso8601"`
+}
+
+type metricDataQuery struct {
+	_ struct{} `type:"structure"`
+
+	Expression *string     `type:"string"`
+	Id         *string     `type:"string"`
+	MetricStat *metricStat `type:"structure"`
+	ReturnData *bool       `type:"boolean"`
+}
+
+type metricStat struct {
+	_ struct{} `type:"structure"`
+
+	Metric *cloudwatch.Metric `type:"structure"`
+	Period *int64             `type:"integer"`
+	Stat   *string            `type:"string"`
+}
+
+type getMetricDataOutput struct {
+	_ struct{} `type:"structure"`
+
+	MetricDataResults []*metricDataResult `type:"list"`
+	NextToken         *string             `type:"string"`
+}
+
+type metricDataResult struct {
+	_ struct{} `type:"structure"`
+
+	Id         *string `type:"string"`
+	Label      *string `type:"string"`
+	StatusCode *string `type:"string"`
+	// The vendored XML unmarshaler cannot decode timestamps inside lists, so
+	// they are kept as ISO 8601 strings
+	Timestamps []*string  `type:"list"`
+	Values     []*float64 `type:"list"`
+}
+
+// getMetricData sends a GetMetricData request
+func (b *CloudWatchBackend) getMetricData(input *getMetricDataInput) (*getMetricDataOutput, error) {
+	op := &request.Operation{
+		Name:       opGetMetricData,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	output := &getMetricDataOutput{}
+	req := b.Connection.NewRequest(op, input, output)
+	return output, req.Send()
+}
+
+// getMetricDataValue evaluates the rule's metric queries and math expression
+// with GetMetricData and returns the newest value of the expression. Without
+// an expression the rule must declare exactly one metric.
+func (b *CloudWatchBackend) getMetricDataValue(rule structs.Rule) (float64, error) {
+	if len(rule.Metrics) == 0 {
+		return 0.0, fmt.Errorf("Missing metric{} stanza for expression in rule %s", rule.Name)
+	}
+	if rule.Expression == "" && len(rule.Metrics) > 1 {
+		return 0.0, fmt.Errorf("Missing expression inside config{} stanza for rule %s, which has %d metrics", rule.Name, len(rule.Metrics))
+	}
+
+	lookback := 3 * time.Hour
+	if rule.Lookback != "" {
+		var err error
+		lookback, err = time.ParseDuration(rule.Lookback)
+		if err != nil {
+			return 0.0, fmt.Errorf("Bad lookback '%s' for rule %s: %s", rule.Lookback, rule.Name, err)
+		}
+	}
+
+	ids := make([]string, 0, len(rule.Metrics))
+	for id := range rule.Metrics {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	queries := []*metricDataQuery{}
+	for _, id := range ids {
+		query, err := metricStatQuery(id, rule.Metrics[id], rule)
+		if err != nil {
+			return 0.0, err
+		}
+		queries = append(queries, query)
+	}
+
+	resultID := ids[0]
+	if rule.Expression != "" {
+		resultID = expressionQueryID
+		queries = append(queries, &metricDataQuery{
+			Id:         aws.String(expressionQueryID),
+			Expression: aws.String(rule.Expression),
+			ReturnData: aws.Bool(true),
+		})
+	} else {
+		queries[0].ReturnData = aws.Bool(true)
+	}
+
+	now := time.Now()
+	input := &getMetricDataInput{
+		EndTime:           aws.Time(now),
+		StartTime:         aws.Time(now.Add(-lookback)),
+		MetricDataQueries: queries,
+		ScanBy:            aws.String("TimestampDescending"),
+	}
+
+	// A result can be split over several pages, which all have to be read
+	var result *metricDataResult
+	for {
+		s, err := b.getMetricData(input)
+		if err != nil {
+			log.Println(err)
+			return 0.0, err
+		}
+		for _, r := range s.MetricDataResults {
+			if aws.StringValue(r.Id) != resultID {
+				continue
+			}
+			if aws.StringValue(r.StatusCode) == "InternalError" || aws.StringValue(r.StatusCode) == "Forbidden" {
+				return 0.0, fmt.Errorf("GetMetricData returned status %s for %s", aws.StringValue(r.StatusCode), resultID)
+			}
+			if result == nil {
+				result = r
+				continue
+			}
+			result.Timestamps = append(result.Timestamps, r.Timestamps...)
+			result.Values = append(result.Values, r.Values...)
+		}
+		if aws.StringValue(s.NextToken) == "" {
+			break
+		}
+		input.NextToken = s.NextToken
+	}
+
+	if result == nil {
+		return 0.0, fmt.Errorf("GetMetricData did not return a result for %s", resultID)
+	}
+	return newestMetricDataValue(result)
+}
+
+// metricStatQuery builds the query for one metric{} stanza. Period and
+// statistic fall back to the values set on the rule itself.
+func metricStatQuery(id string, metric *structs.Rule, rule structs.Rule) (*metricDataQuery, error) {
+	if !metricDataQueryID.MatchString(id) {
+		return nil, fmt.Errorf("Bad metric id '%s' for rule %s: it must start with a lowercase letter and contain only letters, numbers and underscores", id, rule.Name)
+	}
+	if metric.MetricName == "" {
+		return nil, fmt.Errorf("Missing metric_name inside metric{} stanza %s for rule %s", id, rule.Name)
+	}
+	if metric.MetricNamespace == "" {
+		return nil, fmt.Errorf("Missing metric_namespace inside metric{} stanza %s for rule %s", id, rule.Name)
+	}
+	dimensions, err := cloudWatchDimensions(*metric)
+	if err != nil {
+		return nil, err
+	}
+
+	period := int64(metric.MetricPeriod)
+	if period == 0 {
+		period = int64(rule.MetricPeriod)
+	}
+	if period == 0 {
+		period = 300
+	}
+	statistic := metric.Statistic
+	if statistic == "" {
+		statistic = rule.Statistic
+	}
+	if statistic == "" {
+		statistic = "Average"
+	}
+
+	return &metricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &metricStat{
+			Metric: &cloudwatch.Metric{
+				Dimensions: dimensions,
+				MetricName: aws.String(metric.MetricName),
+				Namespace:  aws.String(metric.MetricNamespace),
+			},
+			Period: aws.Int64(period),
+			Stat:   aws.String(statistic),
+		},
+		ReturnData: aws.Bool(false),
+	}, nil
+}
+
+// newestMetricDataValue returns the value with the latest timestamp
+func newestMetricDataValue(result *metricDataResult) (float64, error) {
+	if len(result.Values) == 0 || len(result.Values) != len(result.Timestamps) {
+		return 0.0, fmt.Errorf("no datapoints found for %s", aws.StringValue(result.Id))
+	}
+	newest := -1
+	var newestTime time.Time
+	for i, timestamp := range result.Timestamps {
+		t, err := time.Parse(time.RFC3339, aws.StringValue(timestamp))
+		if err != nil {
+			return 0.0, fmt.Errorf("bad timestamp in GetMetricData result for %s: %s", aws.StringValue(result.Id), err)
+		}
+		if newest == -1 || t.After(newestTime) {
+			newest = i
+			newestTime = t
+		}
+	}
+	return aws.Float64Value(result.Values[newest]), nil
+}
@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/underarmour/libra/structs"
+)
+
+const metricDataPage = `<GetMetricDataResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <GetMetricDataResult>
+    <MetricDataResults>
+      <member>
+        <Id>libra_expression</Id>
+        <Label>libra_expression</Label>
+        <StatusCode>%s</StatusCode>
+        <Timestamps><member>%s</member></Timestamps>
+        <Values><member>%s</member></Values>
+      </member>
+    </MetricDataResults>
+    %s
+  </GetMetricDataResult>
+</GetMetricDataResponse>`
+
+func TestCloudWatchGetMetricData(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "test")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"Action":                        "GetMetricData",
+			"ScanBy":                        "TimestampDescending",
+			"MetricDataQueries.member.1.Id": "healthy",
+			"MetricDataQueries.member.1.MetricStat.Metric.MetricName":                "HealthyHostCount",
+			"MetricDataQueries.member.1.MetricStat.Metric.Namespace":                 "AWS/ApplicationELB",
+			"MetricDataQueries.member.1.MetricStat.Metric.Dimensions.member.1.Name":  "TargetGroup",
+			"MetricDataQueries.member.1.MetricStat.Metric.Dimensions.member.1.Value": "tg",
+			"MetricDataQueries.member.1.MetricStat.Period":                           "60",
+			"MetricDataQueries.member.1.MetricStat.Stat":                             "Average",
+			"MetricDataQueries.member.1.ReturnData":                                  "false",
+			"MetricDataQueries.member.2.Id":                                          "requests",
+			"MetricDataQueries.member.2.MetricStat.Metric.MetricName":                "RequestCount",
+			"MetricDataQueries.member.2.MetricStat.Stat":                             "Sum",
+			"MetricDataQueries.member.3.Id":                                          "libra_expression",
+			"MetricDataQueries.member.3.Expression":                                  "requests / healthy",
+			"MetricDataQueries.member.3.ReturnData":                                  "true",
+		}
+		for key, value := range expected {
+			if r.PostForm.Get(key) != value {
+				t.Errorf("request %d: expected %s to be %q, got %q", requests, key, value, r.PostForm.Get(key))
+			}
+		}
+
+		switch r.PostForm.Get("NextToken") {
+		case "":
+			fmt.Fprintf(w, metricDataPage, "PartialData", "2017-08-04T10:00:00Z", "12.5", "<NextToken>page2</NextToken>")
+		case "page2":
+			fmt.Fprintf(w, metricDataPage, "Complete", "2017-08-04T10:05:00Z", "40", "")
+		default:
+			t.Errorf("unexpected NextToken %q", r.PostForm.Get("NextToken"))
+		}
+	}))
+	defer srv.Close()
+
+	b, err := NewCloudWatchBackend("cloudwatch", CloudWatchConfig{Region: "us-east-1", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := structs.Rule{
+		Name:         "requests per target",
+		Expression:   "requests / healthy",
+		MetricPeriod: 60,
+		Metrics: map[string]*structs.Rule{
+			"requests": {
+				MetricName:      "RequestCount",
+				MetricNamespace: "AWS/ApplicationELB",
+				Statistic:       "Sum",
+			},
+			"healthy": {
+				MetricName:      "HealthyHostCount",
+				MetricNamespace: "AWS/ApplicationELB",
+				Dimensions:      map[string]string{"TargetGroup": "tg"},
+			},
+		},
+	}
+
+	value, err := b.GetValue(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if value != 40 {
+		t.Errorf("expected the newest value 40 from the second page, got %.2f", value)
+	}
+}
+
+func TestCloudWatchGetMetricDataStatus(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "test")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, metricDataPage, "Forbidden", "2017-08-04T10:00:00Z", "1", "")
+	}))
+	defer srv.Close()
+
+	b, err := NewCloudWatchBackend("cloudwatch", CloudWatchConfig{Region: "us-east-1", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := structs.Rule{
+		Name:       "requests per target",
+		Expression: "requests * 2",
+		Metrics: map[string]*structs.Rule{
+			"requests": {MetricName: "RequestCount", MetricNamespace: "AWS/ApplicationELB"},
+		},
+	}
+	if _, err := b.GetValue(rule); err == nil {
+		t.Error("expected an error for a Forbidden result")
+	}
+}
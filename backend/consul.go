@@ -0,0 +1,157 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	consul "github.com/hashicorp/consul/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// ConsulConfig is the configuration for a Consul backend
+type ConsulConfig struct {
+	Name          string
+	Kind          string
+	Host          string
+	Datacenter    string
+	Token         string
+	TLSSkipVerify bool
+	CACert        string
+	ClientCert    string
+	ClientKey     string
+}
+
+// ConsulBackend is a metrics backend that reads service health counts and
+// numeric KV values from Consul
+type ConsulBackend struct {
+	Name       string
+	Config     ConsulConfig
+	Connection *consul.Client
+	httpClient *http.Client
+}
+
+// NewConsulBackend will create a new Consul Client. Settings left empty fall
+// back to the usual CONSUL_HTTP_* environment variables.
+func NewConsulBackend(name string, config ConsulConfig) (*ConsulBackend, error) {
+	consulConfig := consul.DefaultConfig()
+	if config.Host != "" {
+		address := config.Host
+		if i := strings.Index(address, "://"); i != -1 {
+			consulConfig.Scheme = address[:i]
+			address = address[i+3:]
+		}
+		consulConfig.Address = strings.TrimSuffix(address, "/")
+	}
+	if config.Datacenter != "" {
+		consulConfig.Datacenter = config.Datacenter
+	}
+	if config.Token != "" {
+		consulConfig.Token = config.Token
+	}
+	if config.CACert != "" {
+		consulConfig.TLSConfig.CAFile = config.CACert
+	}
+	if config.ClientCert != "" {
+		consulConfig.TLSConfig.CertFile = config.ClientCert
+	}
+	if config.ClientKey != "" {
+		consulConfig.TLSConfig.KeyFile = config.ClientKey
+	}
+	if config.TLSSkipVerify {
+		consulConfig.TLSConfig.InsecureSkipVerify = true
+	}
+
+	// bounded by defaultTimeout until setClientTimeout changes it
+	httpClient, err := consul.NewHttpClient(consulConfig.Transport, consulConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	httpClient.Timeout = defaultTimeout
+	consulConfig.HttpClient = httpClient
+
+	client, err := consul.NewClient(consulConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &ConsulBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = client
+	backend.httpClient = httpClient
+
+	return backend, nil
+}
+
+// GetValue counts the instances of the rule's service in a health state
+// (passing, warning or critical) or in total (instances), or reads a number
+// from the KV store (kv)
+func (b *ConsulBackend) GetValue(rule structs.Rule) (float64, error) {
+	switch rule.MetricName {
+	case "kv":
+		return b.kvValue(rule)
+	case consul.HealthPassing, consul.HealthWarning, consul.HealthCritical, "instances":
+		return b.serviceCount(rule)
+	default:
+		return 0.0, fmt.Errorf("unknown consul metric_name '%s' for rule %s, expected one of passing, warning, critical, instances or kv", rule.MetricName, rule.Name)
+	}
+}
+
+func (b *ConsulBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck asks Consul for its leader
+func (b *ConsulBackend) HealthCheck() error {
+	_, err := b.Connection.Status().Leader()
+	return err
+}
+
+func (b *ConsulBackend) serviceCount(rule structs.Rule) (float64, error) {
+	if rule.Service == "" {
+		return 0.0, fmt.Errorf("Missing service inside config{} stanza for rule %s", rule.Name)
+	}
+
+	entries, _, err := b.Connection.Health().Service(rule.Service, rule.Tag, false, &consul.QueryOptions{})
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	if rule.MetricName == "instances" {
+		return float64(len(entries)), nil
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.Checks.AggregatedStatus() == rule.MetricName {
+			count++
+		}
+	}
+	return float64(count), nil
+}
+
+func (b *ConsulBackend) kvValue(rule structs.Rule) (float64, error) {
+	if rule.Key == "" {
+		return 0.0, fmt.Errorf("Missing key inside config{} stanza for rule %s", rule.Name)
+	}
+
+	pair, _, err := b.Connection.KV().Get(rule.Key, &consul.QueryOptions{})
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	if pair == nil {
+		return 0.0, fmt.Errorf("key %s does not exist in consul", rule.Key)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(pair.Value)), 64)
+	if err != nil {
+		return 0.0, fmt.Errorf("key %s holds %q, expected a number", rule.Key, pair.Value)
+	}
+	return value, nil
+}
@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/nomad"
+)
+
+// Cooldown holds how often a group may be scaled
+type Cooldown struct {
+	// ScaleOut is how long after scaling out the group cannot scale out again
+	ScaleOut time.Duration
+	// ScaleIn is how long after scaling in the group cannot scale in again
+	ScaleIn time.Duration
+	// Stabilization is how far back scale-in looks for higher
+	// recommendations. The group only scales in to the highest of them.
+	Stabilization time.Duration
+}
+
+// NewCooldown reads the cooldown settings of a group
+func NewCooldown(g *nomad.Group) (Cooldown, error) {
+	c := Cooldown{}
+	durations := []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"scale_out_cooldown", g.ScaleOutCooldown, &c.ScaleOut},
+		{"scale_in_cooldown", g.ScaleInCooldown, &c.ScaleIn},
+		{"scale_in_stabilization", g.ScaleInStabilization, &c.Stabilization},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return c, fmt.Errorf("bad %s '%s': %s", d.name, d.value, err)
+		}
+		*d.into = v
+	}
+	return c, nil
+}
+
+type recommendation struct {
+	at    time.Time
+	count int
+}
+
+type groupState struct {
+	cooldown        Cooldown
+	lastScaleOut    time.Time
+	lastScaleIn     time.Time
+	recommendations []recommendation
+}
+
+// groupStates are kept by job/group and survive reloading the rules
+var groupStates = struct {
+	sync.Mutex
+	byGroup map[string]*groupState
+}{byGroup: map[string]*groupState{}}
+
+// stateFor returns the state of a group. The lock must be held.
+func stateFor(job, group string) *groupState {
+	key := job + "/" + group
+	s, ok := groupStates.byGroup[key]
+	if !ok {
+		s = &groupState{}
+		groupStates.byGroup[key] = s
+	}
+	return s
+}
+
+// ConfigureCooldown sets the cooldown settings of a group. Groups that are
+// never configured have no cooldowns.
+func ConfigureCooldown(job, group string, c Cooldown) {
+	groupStates.Lock()
+	defer groupStates.Unlock()
+	stateFor(job, group).cooldown = c
+}
+
+// CooldownStatus is the scaling state of a group
+type CooldownStatus struct {
+	LastScaleOut      *time.Time `json:"last_scale_out,omitempty"`
+	LastScaleIn       *time.Time `json:"last_scale_in,omitempty"`
+	ScaleOutBlockedTo *time.Time `json:"scale_out_blocked_until,omitempty"`
+	ScaleInBlockedTo  *time.Time `json:"scale_in_blocked_until,omitempty"`
+	// ScaleInFloor is the highest count recommended within the
+	// stabilization window. The group does not scale in below it.
+	ScaleInFloor *int `json:"scale_in_floor,omitempty"`
+}
+
+// GroupCooldown returns the scaling state of a group
+func GroupCooldown(job, group string) CooldownStatus {
+	groupStates.Lock()
+	defer groupStates.Unlock()
+	s := stateFor(job, group)
+	now := time.Now()
+
+	status := CooldownStatus{}
+	if !s.lastScaleOut.IsZero() {
+		t := s.lastScaleOut
+		status.LastScaleOut = &t
+		if until := t.Add(s.cooldown.ScaleOut); until.After(now) {
+			status.ScaleOutBlockedTo = &until
+		}
+	}
+	if !s.lastScaleIn.IsZero() {
+		t := s.lastScaleIn
+		status.LastScaleIn = &t
+		if until := t.Add(s.cooldown.ScaleIn); until.After(now) {
+			status.ScaleInBlockedTo = &until
+		}
+	}
+	if floor, ok := s.highestRecommendation(now); ok {
+		status.ScaleInFloor = &floor
+	}
+	return status
+}
+
+// highestRecommendation returns the highest count recommended within the
+// stabilization window, dropping older recommendations
+func (s *groupState) highestRecommendation(now time.Time) (int, bool) {
+	kept := s.recommendations[:0]
+	highest, found := 0, false
+	for _, r := range s.recommendations {
+		if now.Sub(r.at) > s.cooldown.Stabilization {
+			continue
+		}
+		kept = append(kept, r)
+		if !found || r.count > highest {
+			highest, found = r.count, true
+		}
+	}
+	s.recommendations = kept
+	return highest, found
+}
+
+// scaleGroup sets the count of a group from current to desired, unless a
+// cooldown is running. Scaling in only goes down to the highest count
+// recommended within the stabilization window. The evaluation is empty when
+// the count was not changed.
+func scaleGroup(n groupScaler, job, group string, current, desired, min, max int) (string, int, error) {
+	groupStates.Lock()
+	s := stateFor(job, group)
+	now := time.Now()
+	if s.cooldown.Stabilization > 0 {
+		s.recommendations = append(s.recommendations, recommendation{at: now, count: desired})
+		// drops the recommendations that left the window, which would
+		// otherwise pile up while the group is not scaling in
+		s.highestRecommendation(now)
+	}
+
+	if desired > current {
+		if until := s.lastScaleOut.Add(s.cooldown.ScaleOut); now.Before(until) {
+			groupStates.Unlock()
+			log.Infof("Not scaling %s/%s out to %d, scale_out_cooldown ends in %s", job, group, desired, until.Sub(now))
+			return "", current, nil
+		}
+	}
+	if desired < current {
+		if until := s.lastScaleIn.Add(s.cooldown.ScaleIn); now.Before(until) {
+			groupStates.Unlock()
+			log.Infof("Not scaling %s/%s in to %d, scale_in_cooldown ends in %s", job, group, desired, until.Sub(now))
+			return "", current, nil
+		}
+		if floor, ok := s.highestRecommendation(now); ok && floor > desired {
+			log.Infof("Scaling %s/%s in to %d instead of %d, the highest count recommended in the last %s", job, group, floor, desired, s.cooldown.Stabilization)
+			desired = floor
+		}
+		if desired >= current {
+			groupStates.Unlock()
+			return "", current, nil
+		}
+	}
+	groupStates.Unlock()
+
+	if desired == current {
+		return "", current, nil
+	}
+
+	evaluation, newCount, err := n.SetCapacity(job, group, desired, min, max)
+	if err != nil {
+		return "", current, err
+	}
+
+	groupStates.Lock()
+	if newCount > current {
+		s.lastScaleOut = time.Now()
+	} else {
+		s.lastScaleIn = time.Now()
+	}
+	groupStates.Unlock()
+	return evaluation, newCount, nil
+}
@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/datadog"
+	"github.com/underarmour/libra/structs"
+)
+
+// DatadogConfig is the configuration for a Datadog backend
+type DatadogConfig struct {
+	Name   string
+	Kind   string
+	Host   string
+	APIKey string
+	AppKey string
+}
+
+// DatadogBackend is a metrics backend
+type DatadogBackend struct {
+	Name       string
+	Config     DatadogConfig
+	Connection *datadog.Client
+}
+
+// NewDatadogBackend will create a new Datadog Client
+func NewDatadogBackend(name string, config DatadogConfig) (*DatadogBackend, error) {
+	if config.APIKey == "" || config.AppKey == "" {
+		return nil, fmt.Errorf("missing api_key or app_key")
+	}
+	sess := datadog.NewClient(config.Host, config.APIKey, config.AppKey)
+
+	backend := &DatadogBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = sess
+
+	return backend, nil
+}
+
+// GetValue runs the rule's metrics query over the lookback window (default
+// 5m). Every series is reduced over time with reduce (default last) and the
+// series are combined with series_reduce (default avg).
+func (b *DatadogBackend) GetValue(rule structs.Rule) (float64, error) {
+	query := rule.Query
+	if query == "" {
+		return 0.0, fmt.Errorf("Missing query inside config{} stanza for rule %s", rule.Name)
+	}
+
+	lookback := 5 * time.Minute
+	if rule.Lookback != "" {
+		var err error
+		lookback, err = time.ParseDuration(rule.Lookback)
+		if err != nil {
+			return 0.0, fmt.Errorf("Bad lookback '%s' for rule %s: %s", rule.Lookback, rule.Name, err)
+		}
+	}
+
+	now := time.Now()
+	s, err := b.Connection.Query(query, now.Add(-lookback), now)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	if len(s.Series) == 0 {
+		return 0.0, fmt.Errorf("datadog query %q returned no series", query)
+	}
+
+	values := make([][]float64, 0, len(s.Series))
+	for _, series := range s.Series {
+		points := []float64{}
+		for _, p := range series.Pointlist {
+			if v, ok := p.Value(); ok {
+				points = append(points, v)
+			}
+		}
+		values = append(values, points)
+	}
+	return reduceSeries(values, rule.Reduce, rule.SeriesReduce)
+}
+
+func (b *DatadogBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck validates the API key and runs a query over the last minute,
+// which checks the application key
+func (b *DatadogBackend) HealthCheck() error {
+	if err := b.Connection.Validate(); err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err := b.Connection.Query("avg:datadog.estimated_usage.hosts{*}", now.Add(-time.Minute), now)
+	return err
+}
@@ -0,0 +1,163 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/elasticsearch"
+	"github.com/underarmour/libra/structs"
+)
+
+// ElasticsearchConfig is the configuration for an Elasticsearch backend
+type ElasticsearchConfig struct {
+	Name     string
+	Kind     string
+	Host     string
+	Username string
+	Password string
+}
+
+// ElasticsearchBackend is a metrics backend that runs aggregations against
+// Elasticsearch or OpenSearch indices
+type ElasticsearchBackend struct {
+	Name       string
+	Config     ElasticsearchConfig
+	Connection *elasticsearch.Client
+}
+
+// NewElasticsearchBackend will create a new Elasticsearch Client
+func NewElasticsearchBackend(name string, config ElasticsearchConfig) (*ElasticsearchBackend, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	sess := elasticsearch.NewClient(config.Host, config.Username, config.Password)
+
+	backend := &ElasticsearchBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = sess
+
+	return backend, nil
+}
+
+// GetValue runs the rule's query and aggregations against its index over the
+// lookback window. Without aggregations the number of matching documents is
+// returned. With a single aggregation its value is returned, otherwise
+// json_path picks the value out of the response.
+func (b *ElasticsearchBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.Index == "" {
+		return 0.0, fmt.Errorf("Missing index inside config{} stanza for rule %s", rule.Name)
+	}
+
+	request, aggs, err := searchRequest(rule)
+	if err != nil {
+		return 0.0, err
+	}
+	data, err := b.Connection.Search(rule.Index, request)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+
+	path := rule.JSONPath
+	if path == "" {
+		switch len(aggs) {
+		case 0:
+			// hits.total is an object since Elasticsearch 7
+			path = "hits.total.value || hits.total"
+		case 1:
+			for name := range aggs {
+				path = fmt.Sprintf("aggregations.%q.value", name)
+			}
+		default:
+			return 0.0, fmt.Errorf("Missing json_path inside config{} stanza for rule %s, which has %d aggregations", rule.Name, len(aggs))
+		}
+	}
+	result, err := jmespath.Search(path, data)
+	if err != nil {
+		return 0.0, fmt.Errorf("bad json_path '%s' for rule %s: %s", path, rule.Name, err)
+	}
+	value, err := jsonNumber(result)
+	if err != nil {
+		return 0.0, fmt.Errorf("json_path '%s' returned %s", path, err)
+	}
+	return value, nil
+}
+
+func (b *ElasticsearchBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck fetches the cluster's info, which checks the credentials
+func (b *ElasticsearchBackend) HealthCheck() error {
+	return b.Connection.Ping()
+}
+
+// searchRequest wraps the query of the rule's body in a time range filter on
+// time_field (default @timestamp) covering lookback (default 5m). The body is
+// templated like the HTTP backend's.
+func searchRequest(rule structs.Rule) (map[string]interface{}, map[string]interface{}, error) {
+	lookback := 5 * time.Minute
+	if rule.Lookback != "" {
+		var err error
+		lookback, err = time.ParseDuration(rule.Lookback)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Bad lookback '%s' for rule %s: %s", rule.Lookback, rule.Name, err)
+		}
+	}
+	timeField := rule.TimeField
+	if timeField == "" {
+		timeField = "@timestamp"
+	}
+
+	body := map[string]interface{}{}
+	if rule.Body != "" {
+		rendered, err := renderTemplate("body", rule.Body, rule)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal([]byte(rendered), &body); err != nil {
+			return nil, nil, fmt.Errorf("Bad body for rule %s, expected a JSON object: %s", rule.Name, err)
+		}
+	}
+
+	filters := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				timeField: map[string]interface{}{
+					"gte": fmt.Sprintf("now-%ds", int64(lookback.Seconds())),
+					"lte": "now",
+				},
+			},
+		},
+	}
+	if query, ok := body["query"]; ok {
+		filters = append(filters, query)
+	}
+	request := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": filters,
+			},
+		},
+	}
+
+	aggs, _ := body["aggs"].(map[string]interface{})
+	if aggs == nil {
+		aggs, _ = body["aggregations"].(map[string]interface{})
+	}
+	if len(aggs) > 0 {
+		request["aggs"] = aggs
+	} else {
+		// counts above 10,000 are only exact when asked for
+		request["track_total_hits"] = true
+	}
+	return request, aggs, nil
+}
@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// ExecConfig is the configuration for an exec backend
+type ExecConfig struct {
+	Name string
+	Kind string
+}
+
+// ExecBackend is a metrics backend that runs a command and reads a number
+// from its output
+type ExecBackend struct {
+	Name   string
+	Config ExecConfig
+}
+
+// NewExecBackend will create a new exec backend
+func NewExecBackend(name string, config ExecConfig) (*ExecBackend, error) {
+	backend := &ExecBackend{}
+	backend.Name = name
+	backend.Config = config
+
+	return backend, nil
+}
+
+// GetValue runs the rule's command and parses a single float from its stdout.
+// The rule is passed to the command as LIBRA_* environment variables.
+func (b *ExecBackend) GetValue(rule structs.Rule) (float64, error) {
+	if len(rule.Command) == 0 {
+		return 0.0, fmt.Errorf("Missing command inside config{} stanza for rule %s", rule.Name)
+	}
+
+	timeout := 10 * time.Second
+	if rule.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(rule.Timeout)
+		if err != nil {
+			return 0.0, fmt.Errorf("Bad timeout '%s' for rule %s: %s", rule.Timeout, rule.Name, err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(rule.Command[0], rule.Command[1:]...)
+	cmd.Env = append(os.Environ(), ruleEnvironment(rule)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		log.Println(err)
+		return 0.0, commandError(stderr.String(), "command %s failed: %s", rule.Command[0], err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-done:
+	case <-timer.C:
+		// Killing only the command would leave the processes it started
+		// holding its output open, and Wait would wait for them
+		killProcessGroup(cmd)
+		<-done
+		return 0.0, commandError(stderr.String(), "command %s timed out after %s", rule.Command[0], timeout)
+	}
+	if err != nil {
+		log.Println(err)
+		return 0.0, commandError(stderr.String(), "command %s failed: %s", rule.Command[0], err)
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	value, err := strconv.ParseFloat(output, 64)
+	if err != nil {
+		return 0.0, commandError(stderr.String(), "command %s printed %q, expected a single number", rule.Command[0], output)
+	}
+	return value, nil
+}
+
+func (b *ExecBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// cacheKey is the whole rule, since the command sees all of it through its
+// environment
+func (b *ExecBackend) cacheKey(rule structs.Rule) (interface{}, error) {
+	rule.BackendInstance = nil
+	return rule, nil
+}
+
+// commandError formats an error and attaches whatever the command wrote to
+// stderr
+func commandError(stderr string, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if output := strings.TrimSpace(stderr); output != "" {
+		msg += " (stderr: " + output + ")"
+	}
+	return errors.New(msg)
+}
+
+// ruleEnvironment describes a rule as environment variables
+func ruleEnvironment(rule structs.Rule) []string {
+	env := []string{
+		"LIBRA_RULE=" + rule.Name,
+		"LIBRA_JOB=" + rule.Job,
+		"LIBRA_GROUP=" + rule.Group,
+		"LIBRA_BACKEND=" + rule.Backend,
+		"LIBRA_COMPARISON=" + rule.Comparison,
+		"LIBRA_COMPARISON_VALUE=" + strconv.FormatFloat(rule.ComparisonValue, 'f', -1, 64),
+		"LIBRA_ACTION=" + rule.Action,
+		"LIBRA_ACTION_VALUE=" + strconv.Itoa(rule.ActionValue),
+		"LIBRA_METRIC_NAME=" + rule.MetricName,
+		"LIBRA_METRIC_NAMESPACE=" + rule.MetricNamespace,
+		"LIBRA_QUERY=" + rule.Query,
+	}
+	for name, value := range rule.Dimensions {
+		env = append(env, "LIBRA_DIMENSION_"+strings.ToUpper(name)+"="+value)
+	}
+	return env
+}
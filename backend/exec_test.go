@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/underarmour/libra/structs"
+)
+
+func TestExecGetValue(t *testing.T) {
+	b, _ := NewExecBackend("exec", ExecConfig{})
+	rule := structs.Rule{
+		Name:    "queue depth",
+		Command: []string{"sh", "-c", `test "$LIBRA_RULE" = "queue depth" && echo " 4"`},
+	}
+	value, err := b.GetValue(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 4 {
+		t.Errorf("expected 4, got %.2f", value)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	b, _ := NewExecBackend("exec", ExecConfig{})
+	cases := []struct {
+		name    string
+		command []string
+	}{
+		{"command", []string{"sleep", "4"}},
+		// the shell's child keeps the output open after the shell is killed
+		{"grandchild", []string{"sh", "-c", "sleep 4; echo 1"}},
+	}
+	for _, c := range cases {
+		rule := structs.Rule{Name: c.name, Command: c.command, Timeout: "200ms"}
+		start := time.Now()
+		_, err := b.GetValue(rule)
+		if err == nil {
+			t.Errorf("%s: expected a timeout error", c.name)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: expected to return after the 200ms timeout, took %s", c.name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package backend
+
+import (
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command. Processes it started keep running.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,338 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/underarmour/libra/structs"
+)
+
+// ExpressionConfig is the configuration for an expression backend
+type ExpressionConfig struct {
+	Name string
+	Kind string
+}
+
+// ExpressionBackend combines the values of other backends with a math
+// expression
+type ExpressionBackend struct {
+	Name     string
+	Config   ExpressionConfig
+	Backends ConfiguredBackends
+}
+
+// NewExpressionBackend will create a new expression backend. backends is
+// looked up when rules are evaluated, so it may still be filling up.
+func NewExpressionBackend(name string, config ExpressionConfig, backends ConfiguredBackends) (*ExpressionBackend, error) {
+	backend := &ExpressionBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Backends = backends
+
+	return backend, nil
+}
+
+// GetValue queries every metric{} referenced by the rule's expression using
+// the backend the metric names, then evaluates the expression over the
+// results. All failing metrics are reported together.
+func (b *ExpressionBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.Expression == "" {
+		return 0.0, fmt.Errorf("Missing expression inside config{} stanza for rule %s", rule.Name)
+	}
+	expr, err := parseExpression(rule.Expression)
+	if err != nil {
+		return 0.0, fmt.Errorf("Bad expression '%s' for rule %s: %s", rule.Expression, rule.Name, err)
+	}
+
+	ids := expr.identifiers(map[string]bool{})
+	names := make([]string, 0, len(ids))
+	for id := range ids {
+		names = append(names, id)
+	}
+	sort.Strings(names)
+
+	values := map[string]float64{}
+	problems := []string{}
+	for _, id := range names {
+		metric, ok := rule.Metrics[id]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no metric{} stanza", id))
+			continue
+		}
+		value, err := b.metricValue(*metric, rule)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%s): %s", id, metric.Backend, err))
+			continue
+		}
+		values[id] = value
+	}
+	if len(problems) > 0 {
+		return 0.0, fmt.Errorf("rule %s: %s", rule.Name, strings.Join(problems, "; "))
+	}
+
+	return expr.eval(values)
+}
+
+// metricValue queries a single metric{} of rule. The metric inherits the
+// job and group of the rule.
+func (b *ExpressionBackend) metricValue(metric structs.Rule, rule structs.Rule) (float64, error) {
+	if metric.Backend == "" {
+		return 0.0, fmt.Errorf("missing backend")
+	}
+	backend, ok := b.Backends[metric.Backend]
+	if !ok {
+		return 0.0, fmt.Errorf("unknown backend")
+	}
+	if metric.Job == "" {
+		metric.Job = rule.Job
+	}
+	if metric.Group == "" {
+		metric.Group = rule.Group
+	}
+	metric.BackendInstance = backend
+	return backend.GetValue(metric)
+}
+
+// cacheKey includes the job and group, which the metrics inherit
+func (b *ExpressionBackend) cacheKey(rule structs.Rule) (interface{}, error) {
+	return groupCacheKey(rule), nil
+}
+
+func (b *ExpressionBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// exprNode is a node of a parsed expression
+type exprNode struct {
+	op       string // number, ident, neg, call or a binary operator
+	value    float64
+	name     string
+	children []*exprNode
+}
+
+var exprFuncs = map[string]func(args []float64) (float64, error){
+	"abs": func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("abs() takes 1 argument, got %d", len(args))
+		}
+		return math.Abs(args[0]), nil
+	},
+	"ceil": func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("ceil() takes 1 argument, got %d", len(args))
+		}
+		return math.Ceil(args[0]), nil
+	},
+	"floor": func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("floor() takes 1 argument, got %d", len(args))
+		}
+		return math.Floor(args[0]), nil
+	},
+	"min": func(args []float64) (float64, error) {
+		return reduce(args, "min")
+	},
+	"max": func(args []float64) (float64, error) {
+		return reduce(args, "max")
+	},
+}
+
+func (n *exprNode) identifiers(ids map[string]bool) map[string]bool {
+	if n.op == "ident" {
+		ids[n.name] = true
+	}
+	for _, c := range n.children {
+		c.identifiers(ids)
+	}
+	return ids
+}
+
+func (n *exprNode) eval(values map[string]float64) (float64, error) {
+	switch n.op {
+	case "number":
+		return n.value, nil
+	case "ident":
+		return values[n.name], nil
+	}
+
+	args := make([]float64, 0, len(n.children))
+	for _, c := range n.children {
+		v, err := c.eval(values)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, v)
+	}
+
+	switch n.op {
+	case "neg":
+		return -args[0], nil
+	case "call":
+		return exprFuncs[n.name](args)
+	case "+":
+		return args[0] + args[1], nil
+	case "-":
+		return args[0] - args[1], nil
+	case "*":
+		return args[0] * args[1], nil
+	case "/":
+		if args[1] == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return args[0] / args[1], nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", n.op)
+}
+
+// exprParser is a recursive descent parser for +, -, *, /, parentheses,
+// numbers, metric ids and the functions in exprFuncs
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func parseExpression(input string) (*exprNode, error) {
+	p := &exprParser{input: input}
+	n, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected '%c' at position %d", p.input[p.pos], p.pos+1)
+	}
+	return n, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *exprParser) parseSum() (*exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: string(c), children: []*exprNode{left, right}}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseProduct() (*exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '*' || c == '/'; c = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: string(c), children: []*exprNode{left, right}}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{op: "neg", children: []*exprNode{n}}, nil
+	case '+':
+		p.pos++
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (*exprNode, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		n, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+		}
+		p.pos++
+		return n, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number '%s'", p.input[start:p.pos])
+		}
+		return &exprNode{op: "number", value: v}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		if p.peek() != '(' {
+			return &exprNode{op: "ident", name: name}, nil
+		}
+		if _, ok := exprFuncs[name]; !ok {
+			return nil, fmt.Errorf("unknown function %s()", name)
+		}
+		p.pos++
+		n := &exprNode{op: "call", name: name}
+		if p.peek() == ')' {
+			p.pos++
+			return n, nil
+		}
+		for {
+			arg, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, arg)
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case ')':
+				p.pos++
+				return n, nil
+			default:
+				return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected '%c' at position %d", c, p.pos+1)
+}
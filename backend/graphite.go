@@ -37,22 +37,35 @@ func NewGraphiteBackend(name string, config GraphiteConfig) (*GraphiteBackend, e
 	return backend, nil
 }
 
-// GetValue gets a value
+// GetValue renders the rule's target and reduces every series over time with
+// reduce (default last), then combines the series with series_reduce
+// (default avg). Null datapoints are skipped.
 func (b *GraphiteBackend) GetValue(rule structs.Rule) (float64, error) {
 	metricName := rule.MetricName
 	if metricName == "" {
 		return 0.0, fmt.Errorf("Missing metric_name inside config{} stanza")
 	}
 
-	s, err := b.Connection.Render(metricName)
+	series, err := b.Connection.Render(metricName, rule.From, rule.Until)
 	if err != nil {
 		log.Println(err)
 		return 0.0, err
 	}
-	if len(s.Datapoints) == 0 {
-		return 0.0, errors.New("no datapoints found for metric")
+	if len(series) == 0 {
+		return 0.0, errors.New("no series found for metric")
 	}
-	return s.Datapoints[len(s.Datapoints)-1][0], nil
+
+	values := make([][]float64, 0, len(series))
+	for _, s := range series {
+		points := []float64{}
+		for _, d := range s.Datapoints {
+			if v, ok := d.Value(); ok {
+				points = append(points, v)
+			}
+		}
+		values = append(values, points)
+	}
+	return reduceSeries(values, rule.Reduce, rule.SeriesReduce)
 }
 
 func (b *GraphiteBackend) Info() *structs.Backend {
@@ -61,3 +74,9 @@ func (b *GraphiteBackend) Info() *structs.Backend {
 		Name: b.Name,
 	}
 }
+
+// HealthCheck renders a constant series, which checks the credentials
+func (b *GraphiteBackend) HealthCheck() error {
+	_, err := b.Connection.Render("constantLine(1)", "-1min", "now")
+	return err
+}
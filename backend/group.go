@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	api "github.com/hashicorp/nomad/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/nomad"
+	"github.com/underarmour/libra/structs"
+)
+
+// Arbitration strategies, which resolve the counts proposed by the rules of
+// a group to one count
+const (
+	// ArbitrationScaleOutWins picks the highest proposed count
+	ArbitrationScaleOutWins = "scale_out_wins"
+	// ArbitrationLargestChange picks the count furthest from the current
+	// count, preferring scale-out on a tie
+	ArbitrationLargestChange = "largest_change"
+	// ArbitrationUnanimousScaleIn scales out like scale_out_wins, but only
+	// scales in when every rule of the group that can scale in proposes it
+	ArbitrationUnanimousScaleIn = "unanimous_scale_in"
+)
+
+// ValidateArbitration checks the arbitration strategy of a group
+func ValidateArbitration(strategy string) error {
+	switch strategy {
+	case "", ArbitrationScaleOutWins, ArbitrationLargestChange, ArbitrationUnanimousScaleIn:
+		return nil
+	}
+	return fmt.Errorf("unknown arbitration %s, expected one of %s, %s or %s", strategy, ArbitrationScaleOutWins, ArbitrationLargestChange, ArbitrationUnanimousScaleIn)
+}
+
+// proposal is the count a rule wants for its group
+type proposal struct {
+	rule  string
+	count int
+}
+
+// groupScaler reads and sets the count of task groups. It is the Nomad API,
+// or a fake in tests.
+type groupScaler interface {
+	GroupCount(job, group string) (int, error)
+	SetCapacity(job, group string, count, min, max int) (string, int, error)
+}
+
+// nomadScaler scales task groups through the Nomad API
+type nomadScaler struct {
+	client *api.Client
+}
+
+func (s nomadScaler) GroupCount(job, group string) (int, error) {
+	return nomad.GroupCount(s.client, job, group)
+}
+
+func (s nomadScaler) SetCapacity(job, group string, count, min, max int) (string, int, error) {
+	return nomad.SetCapacity(s.client, job, group, count, min, max)
+}
+
+// WorkGroup evaluates every rule of a group together and scales the group
+// once, to the count the group's arbitration picks from the rules'
+// proposals. Rules that fail or have no opinion do not propose a count.
+func WorkGroup(g *nomad.Group, nomadConf *nomad.Config, job string) error {
+	n, err := nomad.NewClient(*nomadConf)
+	if err != nil {
+		log.Errorf("Failed to create Nomad Client: %s", err)
+		return err
+	}
+	return workGroup(nomadScaler{n}, g, job)
+}
+
+func workGroup(n groupScaler, g *nomad.Group, job string) error {
+	current, err := n.GroupCount(job, g.Name)
+	if err != nil {
+		log.Errorf("problem getting count of nomad job/group %s/%s: %s", job, g.Name, err)
+		return err
+	}
+
+	names := make([]string, 0, len(g.Rules))
+	for name := range g.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]*structs.Rule, len(names))
+	results := make([]*proposal, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		rules[i] = g.Rules[name]
+		wg.Add(1)
+		go func(i int, r *structs.Rule) {
+			defer wg.Done()
+			count, ok, err := propose(r, g.Policy, job, g.Name, current, g.MinCount, g.MaxCount)
+			if err != nil || !ok {
+				return
+			}
+			results[i] = &proposal{rule: r.Name, count: count}
+		}(i, rules[i])
+	}
+	wg.Wait()
+
+	proposals := []proposal{}
+	for _, p := range results {
+		if p != nil {
+			proposals = append(proposals, *p)
+		}
+	}
+
+	voters := 0
+	for _, r := range rules {
+		if scalesIn(r, g.Policy) {
+			voters++
+		}
+	}
+
+	desired, ok := arbitrate(g.Arbitration, current, proposals, voters)
+	if !ok {
+		log.Debugf("No rule of %s/%s proposed a count. Not scaling", job, g.Name)
+		return nil
+	}
+	if len(proposals) > 1 && desired != current {
+		log.Infof("Rules of %s/%s proposed %s, %s picked %d", job, g.Name, describeProposals(proposals), arbitration(g.Arbitration), desired)
+	}
+	return applyCount(n, rules, job, g.Name, current, desired, g.MinCount, g.MaxCount)
+}
+
+func arbitration(strategy string) string {
+	if strategy == "" {
+		return ArbitrationScaleOutWins
+	}
+	return strategy
+}
+
+// scalesIn reports whether a rule can propose to scale in: a decrease_count
+// rule, a rule with a step that removes instances, or a target-tracking rule
+func scalesIn(r *structs.Rule, policy string) bool {
+	if policy == PolicyTargetTracking {
+		return true
+	}
+	if len(r.Steps) > 0 {
+		for _, s := range r.Steps {
+			if s.Adjustment < 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return r.Action == "decrease_count"
+}
+
+// arbitrate picks one count from the proposals of a group, of which voters
+// rules can scale in. ok is false when there are no proposals.
+func arbitrate(strategy string, current int, proposals []proposal, voters int) (int, bool) {
+	if len(proposals) == 0 {
+		return 0, false
+	}
+
+	highest := proposals[0].count
+	for _, p := range proposals[1:] {
+		if p.count > highest {
+			highest = p.count
+		}
+	}
+
+	switch arbitration(strategy) {
+	case ArbitrationLargestChange:
+		best := proposals[0].count
+		for _, p := range proposals[1:] {
+			change, bestChange := abs(p.count-current), abs(best-current)
+			if change > bestChange || (change == bestChange && p.count > best) {
+				best = p.count
+			}
+		}
+		return best, true
+	case ArbitrationUnanimousScaleIn:
+		if highest >= current {
+			return highest, true
+		}
+		// Every proposal is below the current count, and so comes from a
+		// rule that can scale in. Rules that only scale out have no say.
+		if len(proposals) < voters {
+			return current, true
+		}
+		return highest, true
+	}
+	return highest, true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func describeProposals(proposals []proposal) string {
+	s := ""
+	for i, p := range proposals {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%d (%s)", p.count, p.rule)
+	}
+	return s
+}
@@ -0,0 +1,397 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/underarmour/libra/nomad"
+	"github.com/underarmour/libra/structs"
+)
+
+// fakeScaler is a task group that remembers the counts it was set to
+type fakeScaler struct {
+	count int
+	sets  []int
+}
+
+func (f *fakeScaler) GroupCount(job, group string) (int, error) {
+	return f.count, nil
+}
+
+func (f *fakeScaler) SetCapacity(job, group string, count, min, max int) (string, int, error) {
+	f.count = count
+	f.sets = append(f.sets, count)
+	return "evaluation", count, nil
+}
+
+func staticRule(name string, value float64) *structs.Rule {
+	b, _ := NewStaticBackend("static", StaticConfig{Value: value})
+	return &structs.Rule{Name: name, Backend: "static", BackendInstance: b}
+}
+
+func thresholdRule(name string, value float64, comparison string, comparisonValue float64, action string, actionValue int) *structs.Rule {
+	r := staticRule(name, value)
+	r.Comparison = comparison
+	r.ComparisonValue = comparisonValue
+	r.Action = action
+	r.ActionValue = actionValue
+	return r
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+func testSteps() map[string]*structs.Step {
+	return map[string]*structs.Step{
+		"low":       {Name: "low", UpperBound: bound(20), Adjustment: -1},
+		"high":      {Name: "high", LowerBound: bound(80), UpperBound: bound(95), Adjustment: 2},
+		"very high": {Name: "very high", LowerBound: bound(95), Adjustment: 5},
+	}
+}
+
+func testGroup(name, policy string, min, max int, rules ...*structs.Rule) *nomad.Group {
+	g := &nomad.Group{
+		Name:     name,
+		MinCount: min,
+		MaxCount: max,
+		Policy:   policy,
+		Rules:    map[string]*structs.Rule{},
+	}
+	for _, r := range rules {
+		g.Rules[r.Name] = r
+	}
+	return g
+}
+
+// replayRule returns a rule that reads values in turn, one per evaluation
+func replayRule(t *testing.T, name string, values []float64) *structs.Rule {
+	dir, err := ioutil.TempDir("", "libra-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "series.json")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewReplayBackend("replay", ReplayConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &structs.Rule{Name: name, Backend: "replay", BackendInstance: b}
+}
+
+func TestWorkGroupThreshold(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     *structs.Rule
+		current  int
+		min, max int
+		expected int
+	}{
+		{"above", thresholdRule("r", 90, "above", 80, "increase_count", 1), 4, 1, 10, 5},
+		{"not above", thresholdRule("r", 50, "above", 80, "increase_count", 1), 4, 1, 10, 4},
+		{"below", thresholdRule("r", 10, "below", 20, "decrease_count", 2), 4, 1, 10, 2},
+		{"equal", thresholdRule("r", 3, "equal", 3, "increase_count", 1), 4, 1, 10, 5},
+		{"not equal", thresholdRule("r", 3, "not_equal", 3, "increase_count", 1), 4, 1, 10, 4},
+		{"above or equal", thresholdRule("r", 80, "above_or_equal", 80, "increase_count", 1), 4, 1, 10, 5},
+		{"below or equal", thresholdRule("r", 20, "below_or_equal", 20, "decrease_count", 1), 4, 1, 10, 3},
+		{"limited to max", thresholdRule("r", 90, "above", 80, "increase_count", 3), 9, 1, 10, 10},
+		{"at max", thresholdRule("r", 90, "above", 80, "increase_count", 1), 10, 1, 10, 10},
+		{"limited to min", thresholdRule("r", 10, "below", 20, "decrease_count", 3), 2, 1, 10, 1},
+		{"unknown action", thresholdRule("r", 90, "above", 80, "restart", 1), 4, 1, 10, 4},
+	}
+	for _, c := range cases {
+		n := &fakeScaler{count: c.current}
+		g := testGroup("threshold "+c.name, PolicyThreshold, c.min, c.max, c.rule)
+		if err := workGroup(n, g, "test"); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+		if n.count != c.expected {
+			t.Errorf("%s: expected count %d, got %d", c.name, c.expected, n.count)
+		}
+		if c.expected == c.current && len(n.sets) > 0 {
+			t.Errorf("%s: expected the count not to be set, got %v", c.name, n.sets)
+		}
+	}
+}
+
+func TestWorkGroupSteps(t *testing.T) {
+	cases := []struct {
+		value    float64
+		current  int
+		max      int
+		expected int
+	}{
+		{10, 4, 10, 3},
+		{50, 4, 10, 4},
+		{80, 4, 10, 6},
+		{94.9, 4, 10, 6},
+		{95, 4, 10, 9},
+		{99, 4, 7, 7},
+		{10, 1, 10, 1},
+	}
+	for _, c := range cases {
+		r := staticRule("r", c.value)
+		r.Steps = testSteps()
+		n := &fakeScaler{count: c.current}
+		g := testGroup(fmt.Sprintf("steps %.1f %d %d", c.value, c.current, c.max), PolicyThreshold, 1, c.max, r)
+		if err := workGroup(n, g, "test"); err != nil {
+			t.Errorf("%.1f: unexpected error: %s", c.value, err)
+		}
+		if n.count != c.expected {
+			t.Errorf("%.1f from %d: expected count %d, got %d", c.value, c.current, c.expected, n.count)
+		}
+	}
+}
+
+func TestWorkGroupTargetTracking(t *testing.T) {
+	cases := []struct {
+		value    float64
+		current  int
+		expected int
+	}{
+		{100, 4, 8},
+		{50, 4, 4},
+		{25, 4, 2},
+		{60, 4, 5},
+		{0, 4, 1},
+		{100, 0, 2},
+		{500, 4, 20},
+	}
+	for _, c := range cases {
+		r := staticRule("r", c.value)
+		r.TargetValue = 50
+		n := &fakeScaler{count: c.current}
+		g := testGroup(fmt.Sprintf("target %.1f %d", c.value, c.current), PolicyTargetTracking, 1, 20, r)
+		if err := workGroup(n, g, "test"); err != nil {
+			t.Errorf("%.1f: unexpected error: %s", c.value, err)
+		}
+		if n.count != c.expected {
+			t.Errorf("%.1f from %d: expected count %d, got %d", c.value, c.current, c.expected, n.count)
+		}
+	}
+}
+
+func TestWorkGroupEvaluationPeriods(t *testing.T) {
+	cases := []struct {
+		name     string
+		values   []float64
+		steps    bool
+		periods  int
+		needed   int
+		expected []int
+	}{
+		{"3 in a row", []float64{90, 90, 90}, false, 3, 0, []int{4, 4, 5}},
+		{"interrupted", []float64{90, 50, 90, 90}, false, 3, 0, []int{4, 4, 4, 4}},
+		{"2 of 3", []float64{90, 50, 90}, false, 3, 2, []int{4, 4, 5}},
+		{"steps in one direction", []float64{85, 85, 85}, true, 3, 0, []int{4, 4, 6}},
+		{"steps in both directions", []float64{85, 85, 10}, true, 3, 0, []int{4, 4, 4}},
+		{"steps of one direction", []float64{85, 99, 85}, true, 3, 0, []int{4, 4, 6}},
+	}
+	for _, c := range cases {
+		r := replayRule(t, "r", c.values)
+		r.EvaluationPeriods = c.periods
+		r.DatapointsToAlarm = c.needed
+		if c.steps {
+			r.Steps = testSteps()
+		} else {
+			r.Comparison = "above"
+			r.ComparisonValue = 80
+			r.Action = "increase_count"
+			r.ActionValue = 1
+		}
+		g := testGroup("evaluation periods "+c.name, PolicyThreshold, 1, 10, r)
+		for i, expected := range c.expected {
+			n := &fakeScaler{count: 4}
+			if err := workGroup(n, g, "test"); err != nil {
+				t.Errorf("%s: unexpected error: %s", c.name, err)
+			}
+			if n.count != expected {
+				t.Errorf("%s: expected count %d after evaluation %d, got %d", c.name, expected, i+1, n.count)
+			}
+		}
+	}
+}
+
+func TestArbitrate(t *testing.T) {
+	cases := []struct {
+		strategy  string
+		proposals []int
+		voters    int
+		expected  int
+		ok        bool
+	}{
+		{"", nil, 1, 0, false},
+		{"", []int{5, 2}, 1, 5, true},
+		{"", []int{3, 2}, 2, 3, true},
+		{ArbitrationScaleOutWins, []int{4, 2}, 1, 4, true},
+		{ArbitrationLargestChange, []int{5, 2}, 1, 2, true},
+		{ArbitrationLargestChange, []int{6, 2}, 1, 6, true},
+		{ArbitrationLargestChange, []int{3, 5}, 1, 5, true},
+		{ArbitrationUnanimousScaleIn, []int{5, 2}, 1, 5, true},
+		{ArbitrationUnanimousScaleIn, []int{2}, 1, 2, true},
+		{ArbitrationUnanimousScaleIn, []int{2}, 2, 4, true},
+		{ArbitrationUnanimousScaleIn, []int{3, 2}, 2, 3, true},
+	}
+	for _, c := range cases {
+		proposals := []proposal{}
+		for i, count := range c.proposals {
+			proposals = append(proposals, proposal{rule: fmt.Sprintf("rule %d", i), count: count})
+		}
+		count, ok := arbitrate(c.strategy, 4, proposals, c.voters)
+		if count != c.expected || ok != c.ok {
+			t.Errorf("%q with %v and %d voters: expected %d, %t, got %d, %t", c.strategy, c.proposals, c.voters, c.expected, c.ok, count, ok)
+		}
+	}
+}
+
+func TestWorkGroupArbitration(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy string
+		current  int
+		rules    []*structs.Rule
+		expected int
+	}{
+		{
+			"scale out wins", ArbitrationScaleOutWins, 4,
+			[]*structs.Rule{
+				thresholdRule("cpu high", 90, "above", 80, "increase_count", 1),
+				thresholdRule("queue low", 5, "below", 10, "decrease_count", 2),
+			},
+			5,
+		},
+		{
+			"scale out at max blocks scale in", ArbitrationScaleOutWins, 10,
+			[]*structs.Rule{
+				thresholdRule("cpu high", 90, "above", 80, "increase_count", 1),
+				thresholdRule("queue low", 5, "below", 10, "decrease_count", 2),
+			},
+			10,
+		},
+		{
+			"largest change", ArbitrationLargestChange, 4,
+			[]*structs.Rule{
+				thresholdRule("cpu high", 90, "above", 80, "increase_count", 1),
+				thresholdRule("queue low", 5, "below", 10, "decrease_count", 2),
+			},
+			2,
+		},
+		{
+			"unanimous without scale-in rules firing", ArbitrationUnanimousScaleIn, 4,
+			[]*structs.Rule{
+				thresholdRule("cpu low", 50, "below", 20, "decrease_count", 1),
+				thresholdRule("queue low", 5, "below", 10, "decrease_count", 2),
+			},
+			4,
+		},
+		{
+			"unanimous with every scale-in rule firing", ArbitrationUnanimousScaleIn, 4,
+			[]*structs.Rule{
+				thresholdRule("cpu high", 10, "above", 80, "increase_count", 1),
+				thresholdRule("cpu low", 10, "below", 20, "decrease_count", 1),
+				thresholdRule("queue low", 5, "below", 10, "decrease_count", 2),
+			},
+			3,
+		},
+		{
+			"failing rule", ArbitrationScaleOutWins, 4,
+			[]*structs.Rule{
+				{Name: "no backend"},
+				thresholdRule("queue low", 5, "below", 10, "decrease_count", 2),
+			},
+			2,
+		},
+	}
+	for _, c := range cases {
+		n := &fakeScaler{count: c.current}
+		g := testGroup("arbitration "+c.name, PolicyThreshold, 1, 10, c.rules...)
+		g.Arbitration = c.strategy
+		if err := workGroup(n, g, "test"); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+		if n.count != c.expected {
+			t.Errorf("%s: expected count %d, got %d", c.name, c.expected, n.count)
+		}
+	}
+}
+
+func TestScaleGroupCooldown(t *testing.T) {
+	type step struct {
+		wait     time.Duration
+		current  int
+		desired  int
+		expected int
+	}
+	window := 100 * time.Millisecond
+	cases := []struct {
+		name     string
+		cooldown Cooldown
+		steps    []step
+	}{
+		{
+			"scale out blocked until scale_out_cooldown", Cooldown{ScaleOut: window},
+			[]step{{0, 4, 5, 5}, {0, 5, 6, 5}, {0, 5, 3, 3}, {150 * time.Millisecond, 3, 6, 6}},
+		},
+		{
+			"scale in blocked until scale_in_cooldown", Cooldown{ScaleIn: window},
+			[]step{{0, 8, 6, 6}, {0, 6, 4, 6}, {0, 6, 7, 7}, {150 * time.Millisecond, 7, 4, 4}},
+		},
+		{
+			"scale in floored to the window", Cooldown{Stabilization: 2 * window},
+			[]step{{0, 10, 6, 6}, {0, 10, 4, 6}, {0, 6, 3, 6}},
+		},
+		{
+			"floor entries expiring", Cooldown{Stabilization: window},
+			[]step{{0, 10, 8, 8}, {0, 8, 5, 8}, {150 * time.Millisecond, 8, 5, 5}},
+		},
+	}
+	for _, c := range cases {
+		group := "cooldown " + c.name
+		ConfigureCooldown("test", group, c.cooldown)
+		for i, s := range c.steps {
+			time.Sleep(s.wait)
+			n := &fakeScaler{count: s.current}
+			if _, _, err := scaleGroup(n, "test", group, s.current, s.desired, 1, 20); err != nil {
+				t.Errorf("%s: unexpected error: %s", c.name, err)
+			}
+			if n.count != s.expected {
+				t.Errorf("%s: step %d from %d to %d: expected count %d, got %d", c.name, i+1, s.current, s.desired, s.expected, n.count)
+			}
+		}
+	}
+}
+
+func TestGroupCooldown(t *testing.T) {
+	group := "cooldown status"
+	ConfigureCooldown("test", group, Cooldown{ScaleOut: time.Minute, Stabilization: time.Minute})
+	if status := GroupCooldown("test", group); status.LastScaleOut != nil || status.ScaleInFloor != nil {
+		t.Errorf("expected an empty status before scaling, got %+v", status)
+	}
+
+	n := &fakeScaler{count: 4}
+	if _, _, err := scaleGroup(n, "test", group, 4, 6, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	status := GroupCooldown("test", group)
+	if status.LastScaleOut == nil || status.ScaleOutBlockedTo == nil {
+		t.Errorf("expected the scale out and its cooldown, got %+v", status)
+	}
+	if status.LastScaleIn != nil || status.ScaleInBlockedTo != nil {
+		t.Errorf("expected no scale in, got %+v", status)
+	}
+	if status.ScaleInFloor == nil || *status.ScaleInFloor != 6 {
+		t.Errorf("expected a scale-in floor of 6, got %+v", status.ScaleInFloor)
+	}
+}
@@ -0,0 +1,348 @@
+package backend
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+const (
+	defaultTimeout          = 30 * time.Second
+	defaultRetryBackoff     = time.Second
+	maxRetryBackoff         = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = time.Minute
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// GuardConfig is how a GuardedBackend calls its backend
+type GuardConfig struct {
+	// Timeout bounds a single attempt
+	Timeout time.Duration
+	// Retries is how many times a failed attempt is retried
+	Retries int
+	// RetryBackoff is the base of the jittered exponential backoff
+	RetryBackoff time.Duration
+	// BreakerThreshold is how many failures in a row open the breaker
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before one call
+	// is let through to try the backend again
+	BreakerCooldown time.Duration
+}
+
+// NewGuardConfig builds a GuardConfig from a backend's configuration,
+// using defaults for anything that is not set
+func NewGuardConfig(backend structs.Backend) (GuardConfig, error) {
+	config := GuardConfig{
+		Timeout:          defaultTimeout,
+		Retries:          backend.Retries,
+		RetryBackoff:     defaultRetryBackoff,
+		BreakerThreshold: defaultBreakerThreshold,
+		BreakerCooldown:  defaultBreakerCooldown,
+	}
+	if backend.Retries < 0 {
+		return config, fmt.Errorf("retries cannot be negative")
+	}
+	if backend.BreakerThreshold > 0 {
+		config.BreakerThreshold = backend.BreakerThreshold
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"timeout", backend.Timeout, &config.Timeout},
+		{"retry_backoff", backend.RetryBackoff, &config.RetryBackoff},
+		{"breaker_cooldown", backend.BreakerCooldown, &config.BreakerCooldown},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return config, fmt.Errorf("bad %s '%s': %s", d.name, d.value, err)
+		}
+		*d.into = v
+	}
+	return config, nil
+}
+
+// GuardedBackend calls another backend with a timeout, retries failed calls,
+// and stops calling it for a while after repeated failures
+type GuardedBackend struct {
+	Name    string
+	Config  GuardConfig
+	Backend structs.Backender
+}
+
+// NewGuardedBackend will wrap backend with timeouts, retries and a circuit
+// breaker
+func NewGuardedBackend(name string, config GuardConfig, backend structs.Backender) *GuardedBackend {
+	return &GuardedBackend{
+		Name:    name,
+		Config:  config,
+		Backend: backend,
+	}
+}
+
+// GetValue gets the value from the wrapped backend. It fails straight away
+// while the backend's breaker is open. Only failures of the backend itself
+// are retried and count toward the breaker; problems with the rule, such as
+// a missing query, are returned right away.
+func (b *GuardedBackend) GetValue(rule structs.Rule) (float64, error) {
+	breaker := breakerFor(b.Name)
+	if !breaker.allow(b.Config.BreakerCooldown) {
+		return 0.0, fmt.Errorf("circuit breaker of backend %s is open", b.Name)
+	}
+
+	var value float64
+	var err error
+	for attempt := 0; attempt <= b.Config.Retries; attempt++ {
+		if attempt > 0 {
+			wait := backoff(b.Config.RetryBackoff, attempt)
+			log.Warnf("problem getting value from backend %s for rule %s, retrying in %s: %s", b.Name, rule.Name, wait, err)
+			time.Sleep(wait)
+		}
+		value, err = b.attempt(rule)
+		if err == nil {
+			breaker.success()
+			return value, nil
+		}
+		if !backendFailure(err) {
+			breaker.release()
+			return 0.0, err
+		}
+	}
+
+	if breaker.failure(b.Config.BreakerThreshold) {
+		log.Errorf("Backend %s failed %d times in a row, not calling it for %s", b.Name, b.Config.BreakerThreshold, b.Config.BreakerCooldown)
+	}
+	return 0.0, err
+}
+
+// timeoutError is returned when an attempt takes longer than the timeout
+type timeoutError struct {
+	backend string
+	timeout time.Duration
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("backend %s timed out after %s", e.backend, e.timeout)
+}
+
+// serverStatus matches the 5xx statuses in the errors of the HTTP clients,
+// e.g. "graphite returned 503 Service Unavailable", and of the Nomad and
+// Consul APIs, e.g. "Unexpected response code: 500"
+var serverStatus = regexp.MustCompile(`(returned|response code:) 5\d\d\b`)
+
+// backendFailure reports whether err is a failure of the backend itself: a
+// timeout, a connection problem or a 5xx response
+func backendFailure(err error) bool {
+	switch e := err.(type) {
+	case timeoutError:
+		return true
+	case net.Error:
+		// includes the *url.Error of net/http clients
+		return true
+	case awserr.RequestFailure:
+		if e.StatusCode() >= 500 {
+			return true
+		}
+	}
+	if request.IsErrorRetryable(err) || request.IsErrorThrottle(err) {
+		return true
+	}
+	return serverStatus.MatchString(err.Error())
+}
+
+// attempt makes a single call. A call that times out keeps running in the
+// background until the backend's own client gives up.
+func (b *GuardedBackend) attempt(rule structs.Rule) (float64, error) {
+	type result struct {
+		value float64
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := b.Backend.GetValue(rule)
+		done <- result{value, err}
+	}()
+
+	timer := time.NewTimer(b.Config.Timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timer.C:
+		return 0.0, timeoutError{b.Name, b.Config.Timeout}
+	}
+}
+
+// setClientTimeout gives the client of a backend the backend's timeout, so
+// a timeout longer than the client's own default takes effect
+func setClientTimeout(backend structs.Backender, timeout time.Duration) {
+	switch b := backend.(type) {
+	case *GraphiteBackend:
+		b.Connection.HTTP.Timeout = timeout
+	case *PrometheusBackend:
+		b.Connection.HTTP.Timeout = timeout
+	case *InfluxDBBackend:
+		b.Connection.HTTP.Timeout = timeout
+	case *ElasticsearchBackend:
+		b.Connection.HTTP.Timeout = timeout
+	case *DatadogBackend:
+		b.Connection.HTTP.Timeout = timeout
+	case *HTTPBackend:
+		b.Connection.Timeout = timeout
+	case *KafkaBackend:
+		b.Connection.Timeout = timeout
+	case *RedisBackend:
+		b.Connection.Timeout = timeout
+	case *CloudWatchBackend:
+		b.Connection.Config.HTTPClient.Timeout = timeout
+	case *SQSBackend:
+		b.Connection.Config.HTTPClient.Timeout = timeout
+		b.nomadHTTP.Timeout = timeout
+	case *ConsulBackend:
+		b.httpClient.Timeout = timeout
+	case *NomadBackend:
+		b.httpClient.Timeout = timeout
+	}
+}
+
+func (b *GuardedBackend) Info() *structs.Backend {
+	return b.Backend.Info()
+}
+
+// Unwrap returns the wrapped backend
+func (b *GuardedBackend) Unwrap() structs.Backender {
+	return b.Backend
+}
+
+// backoff returns a random wait of up to base * 2^(attempt-1), capped at
+// maxRetryBackoff
+func backoff(base time.Duration, attempt int) time.Duration {
+	max := base
+	for i := 1; i < attempt && max < maxRetryBackoff; i++ {
+		max *= 2
+	}
+	if max > maxRetryBackoff {
+		max = maxRetryBackoff
+	}
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max))) + 1
+}
+
+// BreakerStatus is the state of a backend's circuit breaker
+type BreakerStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+type circuitBreaker struct {
+	sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// breakers are kept by backend name, so they survive reloading the backends
+var breakers = struct {
+	sync.Mutex
+	byName map[string]*circuitBreaker
+}{byName: map[string]*circuitBreaker{}}
+
+func breakerFor(name string) *circuitBreaker {
+	breakers.Lock()
+	defer breakers.Unlock()
+	b, ok := breakers.byName[name]
+	if !ok {
+		b = &circuitBreaker{state: BreakerClosed}
+		breakers.byName[name] = b
+	}
+	return b
+}
+
+// Breaker returns the state of the named backend's circuit breaker
+func Breaker(name string) BreakerStatus {
+	b := breakerFor(name)
+	b.Lock()
+	defer b.Unlock()
+	status := BreakerStatus{
+		State:    b.state,
+		Failures: b.failures,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// allow reports whether a call may be made. Once the cooldown has passed,
+// a single call is let through.
+func (b *circuitBreaker) allow(cooldown time.Duration) bool {
+	b.Lock()
+	defer b.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		return false
+	}
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.Lock()
+	defer b.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+}
+
+// release gives back the call let through by a half-open breaker without a
+// verdict, so the next call tries the backend again
+func (b *circuitBreaker) release() {
+	b.Lock()
+	defer b.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+}
+
+// failure records a failed call and reports whether it opened the breaker
+func (b *circuitBreaker) failure(threshold int) bool {
+	b.Lock()
+	defer b.Unlock()
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= threshold) {
+		opened := b.state == BreakerClosed
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		return opened
+	}
+	return false
+}
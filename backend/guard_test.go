@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/underarmour/libra/structs"
+)
+
+func TestSetClientTimeout(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "test")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cw, err := NewCloudWatchBackend("cloudwatch", CloudWatchConfig{Region: "us-east-1", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewConsulBackend("consul", ConsulConfig{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := NewNomadBackend("nomad", NomadConfig{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		backend structs.Backender
+		request func() error
+	}{
+		{"cloudwatch", cw, func() error {
+			_, err := cw.Connection.ListMetrics(&cloudwatch.ListMetricsInput{})
+			return err
+		}},
+		{"consul", c, func() error {
+			_, err := c.Connection.Status().Leader()
+			return err
+		}},
+		{"nomad", n, func() error {
+			_, _, err := n.Connection.Jobs().List(nil)
+			return err
+		}},
+	}
+	for _, tc := range cases {
+		setClientTimeout(tc.backend, 100*time.Millisecond)
+		start := time.Now()
+		if err := tc.request(); err == nil {
+			t.Errorf("%s: expected the request to time out", tc.name)
+		}
+		// the AWS client retries a few times on its own
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("%s: expected the client timeout to end the request, took %s", tc.name, elapsed)
+		}
+	}
+}
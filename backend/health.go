@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/underarmour/libra/structs"
+)
+
+// ErrHealthCheckUnsupported is returned by HealthCheck for backends that
+// cannot be checked without a rule
+var ErrHealthCheckUnsupported = errors.New("backend has no health check")
+
+// HealthCheck checks a backend's connection and credentials and returns how
+// long the check took. The check is bounded by the backend's timeout but
+// bypasses its retries and circuit breaker.
+func HealthCheck(backend structs.Backender) (time.Duration, error) {
+	timeout := defaultTimeout
+	for b := backend; b != nil; {
+		if guarded, ok := b.(*GuardedBackend); ok {
+			timeout = guarded.Config.Timeout
+			break
+		}
+		w, ok := b.(interface {
+			Unwrap() structs.Backender
+		})
+		if !ok {
+			break
+		}
+		b = w.Unwrap()
+	}
+
+	checker, ok := unwrap(backend).(structs.HealthChecker)
+	if !ok {
+		return 0, ErrHealthCheckUnsupported
+	}
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- checker.HealthCheck()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-timer.C:
+		return time.Since(start), fmt.Errorf("health check timed out after %s", timeout)
+	}
+}
@@ -0,0 +1,222 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// HTTPConfig is the configuration for a generic HTTP/JSON backend
+type HTTPConfig struct {
+	Name     string
+	Kind     string
+	Host     string
+	Username string
+	Password string
+	Headers  map[string]string
+}
+
+// HTTPBackend is a metrics backend that reads a number out of any JSON
+// endpoint
+type HTTPBackend struct {
+	Name       string
+	Config     HTTPConfig
+	Connection *http.Client
+}
+
+// templateFuncs are available in templated urls, headers and bodies
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+}
+
+// NewHTTPBackend will create a new HTTP Client
+func NewHTTPBackend(name string, config HTTPConfig) (*HTTPBackend, error) {
+	backend := &HTTPBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = &http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	return backend, nil
+}
+
+// GetValue requests the rule's url and extracts a number from the JSON
+// response with the rule's json_path JMESPath expression. The url, headers
+// and body are Go templates rendered with the rule, e.g. {{.Job}}, and may
+// read environment variables with {{env "NAME"}}. A list of numbers is
+// reduced with reduce (default avg).
+func (b *HTTPBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.URL == "" {
+		return 0.0, fmt.Errorf("Missing url inside config{} stanza for rule %s", rule.Name)
+	}
+	if rule.JSONPath == "" {
+		return 0.0, fmt.Errorf("Missing json_path inside config{} stanza for rule %s", rule.Name)
+	}
+
+	req, err := b.newRequest(rule)
+	if err != nil {
+		return 0.0, err
+	}
+	resp, err := b.Connection.Do(req)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0.0, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0.0, fmt.Errorf("%s %s returned %s", req.Method, req.URL, resp.Status)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return 0.0, fmt.Errorf("problem parsing response from %s: %s", req.URL, err)
+	}
+	result, err := jmespath.Search(rule.JSONPath, data)
+	if err != nil {
+		return 0.0, fmt.Errorf("bad json_path '%s' for rule %s: %s", rule.JSONPath, rule.Name, err)
+	}
+
+	switch v := result.(type) {
+	case []interface{}:
+		values := make([]float64, 0, len(v))
+		for _, item := range v {
+			value, err := jsonNumber(item)
+			if err != nil {
+				return 0.0, fmt.Errorf("json_path '%s' returned %s", rule.JSONPath, err)
+			}
+			values = append(values, value)
+		}
+		return reduce(values, rule.Reduce)
+	default:
+		value, err := jsonNumber(v)
+		if err != nil {
+			return 0.0, fmt.Errorf("json_path '%s' returned %s", rule.JSONPath, err)
+		}
+		return value, nil
+	}
+}
+
+func (b *HTTPBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// cacheKey is the rendered request, since its templates can use any field of
+// the rule
+func (b *HTTPBackend) cacheKey(rule structs.Rule) (interface{}, error) {
+	req, err := b.newRequest(rule)
+	if err != nil {
+		return nil, err
+	}
+	body := []byte{}
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []interface{}{req.Method, req.URL.String(), string(body), req.Header, rule.JSONPath, rule.Reduce}, nil
+}
+
+// newRequest renders the templated parts of a rule into a request. Relative
+// urls are resolved against the backend host.
+func (b *HTTPBackend) newRequest(rule structs.Rule) (*http.Request, error) {
+	url, err := renderTemplate("url", rule.URL, rule)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(url, "://") {
+		url = strings.TrimSuffix(b.Config.Host, "/") + "/" + strings.TrimPrefix(url, "/")
+	}
+
+	method := strings.ToUpper(rule.Method)
+	if method == "" {
+		method = "GET"
+	}
+	var body io.Reader
+	if rule.Body != "" {
+		rendered, err := renderTemplate("body", rule.Body, rule)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(rendered)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if b.Config.Username != "" {
+		req.SetBasicAuth(b.Config.Username, b.Config.Password)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	headers := map[string]string{}
+	for k, v := range b.Config.Headers {
+		headers[k] = v
+	}
+	for k, v := range rule.Headers {
+		headers[k] = v
+	}
+	for k, v := range headers {
+		rendered, err := renderTemplate("header "+k, v, rule)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(k, rendered)
+	}
+	return req, nil
+}
+
+func renderTemplate(name, text string, rule structs.Rule) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("bad %s template for rule %s: %s", name, rule.Name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, rule); err != nil {
+		return "", fmt.Errorf("problem rendering %s template for rule %s: %s", name, rule.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// jsonNumber converts a decoded JSON value into a float. Numeric strings are
+// accepted since some endpoints quote their numbers.
+func jsonNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0.0, fmt.Errorf("non-numeric string %q", n)
+		}
+		return f, nil
+	case nil:
+		return 0.0, fmt.Errorf("no value")
+	default:
+		return 0.0, fmt.Errorf("non-numeric value %v", n)
+	}
+}
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/influxdb"
+	"github.com/underarmour/libra/structs"
+)
+
+// InfluxDBConfig is the configuration for an InfluxDB backend
+type InfluxDBConfig struct {
+	Name     string
+	Kind     string
+	Host     string
+	Username string
+	Password string
+	Token    string
+	Database string
+	Org      string
+	Bucket   string
+}
+
+// InfluxDBBackend is a metrics backend
+type InfluxDBBackend struct {
+	Name       string
+	Config     InfluxDBConfig
+	Connection *influxdb.Client
+}
+
+// NewInfluxDBBackend will create a new InfluxDB Client
+func NewInfluxDBBackend(name string, config InfluxDBConfig) (*InfluxDBBackend, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	sess := influxdb.NewClient(config.Host, config.Username, config.Password, config.Token)
+
+	backend := &InfluxDBBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = sess
+
+	return backend, nil
+}
+
+// GetValue runs the rule's InfluxQL or Flux query. Every series is reduced
+// over time with reduce (default last) and the series are combined with
+// series_reduce (default avg).
+func (b *InfluxDBBackend) GetValue(rule structs.Rule) (float64, error) {
+	query := rule.Query
+	if query == "" {
+		return 0.0, fmt.Errorf("Missing query inside config{} stanza for rule %s", rule.Name)
+	}
+
+	var series []influxdb.Series
+	var err error
+	switch rule.QueryLanguage {
+	case "", "influxql":
+		if b.Config.Database == "" {
+			return 0.0, fmt.Errorf("Missing database for InfluxQL query in backend %s", b.Name)
+		}
+		series, err = b.Connection.Query(b.Config.Database, query)
+	case "flux":
+		// expose the configured bucket to the query as a variable
+		if b.Config.Bucket != "" {
+			query = "bucket = " + strconv.Quote(b.Config.Bucket) + "\n" + query
+		}
+		series, err = b.Connection.QueryFlux(b.Config.Org, query)
+	default:
+		return 0.0, fmt.Errorf("unknown query_language '%s' for rule %s, expected influxql or flux", rule.QueryLanguage, rule.Name)
+	}
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	if len(series) == 0 {
+		return 0.0, fmt.Errorf("influxdb query %q returned no series", query)
+	}
+
+	values := make([][]float64, 0, len(series))
+	for _, s := range series {
+		values = append(values, s.Values)
+	}
+	return reduceSeries(values, rule.Reduce, rule.SeriesReduce)
+}
+
+func (b *InfluxDBBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck lists one measurement of the database with InfluxQL, or one
+// bucket with Flux
+func (b *InfluxDBBackend) HealthCheck() error {
+	var err error
+	if b.Config.Database != "" {
+		_, err = b.Connection.Query(b.Config.Database, "SHOW MEASUREMENTS LIMIT 1")
+	} else {
+		_, err = b.Connection.QueryFlux(b.Config.Org, "buckets() |> limit(n: 1)")
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+
+	rootcerts "github.com/hashicorp/go-rootcerts"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/kafka"
+	"github.com/underarmour/libra/structs"
+)
+
+// KafkaConfig is the configuration for a Kafka backend
+type KafkaConfig struct {
+	Name          string
+	Kind          string
+	Brokers       []string
+	TLS           bool
+	TLSSkipVerify bool
+	CACert        string
+}
+
+// KafkaBackend is a metrics backend that reads consumer group lag
+type KafkaBackend struct {
+	Name       string
+	Config     KafkaConfig
+	Connection *kafka.Client
+}
+
+// NewKafkaBackend will create a new Kafka Client
+func NewKafkaBackend(name string, config KafkaConfig) (*KafkaBackend, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("missing host")
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLS {
+		tlsConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: config.TLSSkipVerify,
+		}
+		if err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{CAFile: config.CACert}); err != nil {
+			return nil, err
+		}
+	}
+	for _, broker := range config.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			return nil, fmt.Errorf("bad broker '%s', expected host:port: %s", broker, err)
+		}
+	}
+	sess := kafka.NewClient(config.Brokers, tlsConfig)
+
+	backend := &KafkaBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = sess
+
+	return backend, nil
+}
+
+// GetValue gets the lag of the rule's consumer group on its topic, summed over
+// all partitions by default or the largest partition lag with reduce = "max"
+func (b *KafkaBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.Topic == "" {
+		return 0.0, fmt.Errorf("Missing topic inside config{} stanza for rule %s", rule.Name)
+	}
+	if rule.ConsumerGroup == "" {
+		return 0.0, fmt.Errorf("Missing consumer_group inside config{} stanza for rule %s", rule.Name)
+	}
+
+	lags, err := b.Connection.ConsumerLag(rule.ConsumerGroup, kafkaTopics(rule))
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	if len(lags) == 0 {
+		return 0.0, fmt.Errorf("topic %s has no partitions", rule.Topic)
+	}
+
+	values := make([]float64, 0, len(lags))
+	for _, lag := range lags {
+		values = append(values, float64(lag.Lag))
+	}
+	method := rule.Reduce
+	if method == "" {
+		method = "sum"
+	}
+	return reduce(values, method)
+}
+
+// CountLimit caps the group at the number of partitions of the rule's topic,
+// since consumers beyond that sit idle
+func (b *KafkaBackend) CountLimit(rule structs.Rule) (int, error) {
+	if rule.Topic == "" {
+		return 0, fmt.Errorf("Missing topic inside config{} stanza for rule %s", rule.Name)
+	}
+	return b.Connection.PartitionCount(kafkaTopics(rule))
+}
+
+func (b *KafkaBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck asks the brokers for cluster metadata
+func (b *KafkaBackend) HealthCheck() error {
+	return b.Connection.Ping()
+}
+
+// kafkaTopics splits a comma separated topic list
+func kafkaTopics(rule structs.Rule) []string {
+	topics := []string{}
+	for _, topic := range strings.Split(rule.Topic, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
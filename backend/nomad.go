@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+
+	api "github.com/hashicorp/nomad/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/nomad"
+	"github.com/underarmour/libra/structs"
+)
+
+// NomadConfig is the configuration for a Nomad allocation stats backend
+type NomadConfig struct {
+	Name    string
+	Kind    string
+	Address string
+}
+
+// NomadBackend is a metrics backend that reads resource usage straight from
+// the allocations of the group being scaled
+type NomadBackend struct {
+	Name       string
+	Config     NomadConfig
+	Connection *api.Client
+	httpClient *http.Client
+}
+
+// NewNomadBackend will create a new Nomad Client
+func NewNomadBackend(name string, config NomadConfig) (*NomadBackend, error) {
+	client, httpClient, err := newNomadClient(config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &NomadBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = client
+	backend.httpClient = httpClient
+
+	return backend, nil
+}
+
+// newNomadClient creates a Nomad client for a backend. Its requests are
+// bounded by defaultTimeout until setClientTimeout changes it.
+func newNomadClient(address string) (*api.Client, *http.Client, error) {
+	httpClient := api.DefaultConfig().HttpClient
+	httpClient.Timeout = defaultTimeout
+	client, err := nomad.NewClient(nomad.Config{Address: address, HTTPClient: httpClient})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, httpClient, nil
+}
+
+// GetValue gets the resource usage of every running allocation of the rule's
+// job/group and reduces it to one value. The count metric is the number of
+// running allocations.
+func (b *NomadBackend) GetValue(rule structs.Rule) (float64, error) {
+	metricName := rule.MetricName
+	if metricName == "" {
+		return 0.0, fmt.Errorf("Missing metric_name inside config{} stanza for rule %s", rule.Name)
+	}
+
+	allocs, err := nomad.RunningAllocations(b.Connection, rule.Job, rule.Group)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	if metricName == "count" {
+		return float64(len(allocs)), nil
+	}
+	if len(allocs) == 0 {
+		return 0.0, fmt.Errorf("no running allocations found for %s/%s", rule.Job, rule.Group)
+	}
+
+	values := []float64{}
+	for _, alloc := range allocs {
+		stats, err := b.Connection.Allocations().Stats(alloc, &api.QueryOptions{})
+		if err != nil {
+			log.Warnf("problem getting stats for allocation %s: %s", alloc.ID, err)
+			continue
+		}
+		value, err := allocationMetric(metricName, alloc, stats)
+		if err != nil {
+			return 0.0, err
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return 0.0, fmt.Errorf("could not get stats for any of the %d allocations of %s/%s", len(allocs), rule.Job, rule.Group)
+	}
+
+	return reduce(values, rule.Reduce)
+}
+
+// cacheKey includes the job and group, whose allocations are read
+func (b *NomadBackend) cacheKey(rule structs.Rule) (interface{}, error) {
+	return groupCacheKey(rule), nil
+}
+
+func (b *NomadBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck asks Nomad for its leader
+func (b *NomadBackend) HealthCheck() error {
+	_, err := b.Connection.Status().Leader()
+	return err
+}
+
+// allocationMetric extracts a single metric from an allocation's resource usage
+func allocationMetric(metricName string, alloc *api.Allocation, stats *api.AllocResourceUsage) (float64, error) {
+	if stats.ResourceUsage == nil || stats.ResourceUsage.CpuStats == nil || stats.ResourceUsage.MemoryStats == nil {
+		return 0.0, fmt.Errorf("allocation %s did not report resource usage", alloc.ID)
+	}
+	cpu := stats.ResourceUsage.CpuStats
+	memory := stats.ResourceUsage.MemoryStats
+
+	switch metricName {
+	case "cpu_percent":
+		if alloc.Resources == nil || alloc.Resources.CPU == nil || *alloc.Resources.CPU == 0 {
+			return 0.0, fmt.Errorf("allocation %s has no CPU resources", alloc.ID)
+		}
+		return cpu.TotalTicks / float64(*alloc.Resources.CPU) * 100, nil
+	case "memory_percent":
+		if alloc.Resources == nil || alloc.Resources.MemoryMB == nil || *alloc.Resources.MemoryMB == 0 {
+			return 0.0, fmt.Errorf("allocation %s has no memory resources", alloc.ID)
+		}
+		return float64(memory.RSS) / float64(*alloc.Resources.MemoryMB*1024*1024) * 100, nil
+	case "cpu_mhz":
+		return cpu.TotalTicks, nil
+	case "memory_mb":
+		return float64(memory.RSS) / 1024 / 1024, nil
+	default:
+		return 0.0, fmt.Errorf("unknown nomad metric '%s', expected one of count, cpu_percent, memory_percent, cpu_mhz or memory_mb", metricName)
+	}
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/prometheus"
+	"github.com/underarmour/libra/structs"
+)
+
+// PrometheusConfig is the configuration for a Prometheus backend
+type PrometheusConfig struct {
+	Name        string
+	Kind        string
+	Host        string
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// PrometheusBackend is a metrics backend
+type PrometheusBackend struct {
+	Name       string
+	Config     PrometheusConfig
+	Connection *prometheus.Client
+}
+
+// NewPrometheusBackend will create a new Prometheus Client
+func NewPrometheusBackend(name string, config PrometheusConfig) (*PrometheusBackend, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	sess := prometheus.NewClient(config.Host, config.Username, config.Password, config.BearerToken)
+
+	backend := &PrometheusBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = sess
+
+	return backend, nil
+}
+
+// GetValue runs the rule's PromQL query and returns its single result
+func (b *PrometheusBackend) GetValue(rule structs.Rule) (float64, error) {
+	query := rule.Query
+	if query == "" {
+		return 0.0, fmt.Errorf("Missing query inside config{} stanza for rule %s", rule.Name)
+	}
+
+	s, err := b.Connection.Query(query)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+	value, err := s.Value()
+	if err != nil {
+		return 0.0, fmt.Errorf("prometheus query %q: %s", query, err)
+	}
+	return value, nil
+}
+
+func (b *PrometheusBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck runs a query that needs no data
+func (b *PrometheusBackend) HealthCheck() error {
+	_, err := b.Connection.Query("vector(1)")
+	return err
+}
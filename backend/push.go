@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/underarmour/libra/structs"
+)
+
+const (
+	// pushMaxPoints is how many points are kept for each pushed metric
+	pushMaxPoints = 1000
+	// pushMaxMetrics is how many distinct metric names are kept
+	pushMaxMetrics = 10000
+	// pushRetention is how long a metric is kept after its newest point
+	// once there are pushMaxMetrics of them
+	pushRetention = time.Hour
+	// pushMaxAge is the default staleness limit of a push rule
+	pushMaxAge = 5 * time.Minute
+	// pushMaxSkew is how far ahead of Libra's clock a timestamp may be
+	pushMaxSkew = time.Minute
+)
+
+type pushPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// pushStore holds the values POSTed to /push. Every metric keeps its newest
+// pushMaxPoints points in time order.
+var pushStore = struct {
+	sync.RWMutex
+	series map[string][]pushPoint
+}{series: map[string][]pushPoint{}}
+
+// ValidatePushTimestamp rejects timestamps too far in the future, such as
+// milliseconds sent as seconds. They would stay the newest point forever.
+func ValidatePushTimestamp(timestamp time.Time) error {
+	if limit := time.Now().Add(pushMaxSkew); timestamp.After(limit) {
+		return fmt.Errorf("timestamp %d is more than %s in the future", timestamp.Unix(), pushMaxSkew)
+	}
+	return nil
+}
+
+// Push records a value for the named metric. A zero timestamp means now.
+func Push(name string, value float64, timestamp time.Time) error {
+	if name == "" {
+		return errors.New("metric name cannot be empty")
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if err := ValidatePushTimestamp(timestamp); err != nil {
+		return err
+	}
+
+	pushStore.Lock()
+	defer pushStore.Unlock()
+
+	points, ok := pushStore.series[name]
+	if !ok && len(pushStore.series) >= pushMaxMetrics {
+		evictPushed(time.Now().Add(-pushRetention))
+		if len(pushStore.series) >= pushMaxMetrics {
+			return fmt.Errorf("too many pushed metrics, %s was dropped", name)
+		}
+	}
+
+	// Points usually arrive in order, so walk back from the end to keep the
+	// series sorted.
+	i := len(points)
+	for i > 0 && points[i-1].Timestamp.After(timestamp) {
+		i--
+	}
+	points = append(points, pushPoint{})
+	copy(points[i+1:], points[i:])
+	points[i] = pushPoint{Timestamp: timestamp, Value: value}
+
+	if len(points) > pushMaxPoints {
+		points = append([]pushPoint{}, points[len(points)-pushMaxPoints:]...)
+	}
+	pushStore.series[name] = points
+	return nil
+}
+
+// evictPushed drops the metrics whose newest point is older than before. The
+// lock must be held.
+func evictPushed(before time.Time) {
+	for name, points := range pushStore.series {
+		if len(points) == 0 || points[len(points)-1].Timestamp.Before(before) {
+			delete(pushStore.series, name)
+		}
+	}
+}
+
+// pushedSince returns the values of the named metric newer than since, oldest
+// first, along with the time of the newest point.
+func pushedSince(name string, since time.Time) ([]float64, time.Time, bool) {
+	pushStore.RLock()
+	defer pushStore.RUnlock()
+
+	points, ok := pushStore.series[name]
+	if !ok || len(points) == 0 {
+		return nil, time.Time{}, false
+	}
+	values := []float64{}
+	for _, p := range points {
+		if p.Timestamp.After(since) {
+			values = append(values, p.Value)
+		}
+	}
+	return values, points[len(points)-1].Timestamp, true
+}
+
+// PushConfig is the configuration for a push backend
+type PushConfig struct {
+	Name string
+	Kind string
+}
+
+// PushBackend reads metrics pushed to the Libra API
+type PushBackend struct {
+	Name   string
+	Config PushConfig
+}
+
+// NewPushBackend will create a new push backend
+func NewPushBackend(name string, config PushConfig) (*PushBackend, error) {
+	backend := &PushBackend{}
+	backend.Name = name
+	backend.Config = config
+
+	return backend, nil
+}
+
+// GetValue reduces the values pushed for the rule's metric_name over the
+// lookback window (default max_age) with reduce (default last). The metric
+// is stale when nothing was pushed within max_age (default 5m).
+func (b *PushBackend) GetValue(rule structs.Rule) (float64, error) {
+	name := rule.MetricName
+	if name == "" {
+		return 0.0, fmt.Errorf("Missing metric_name inside config{} stanza for rule %s", rule.Name)
+	}
+
+	maxAge := pushMaxAge
+	if rule.MaxAge != "" {
+		var err error
+		maxAge, err = time.ParseDuration(rule.MaxAge)
+		if err != nil {
+			return 0.0, fmt.Errorf("Bad max_age '%s' for rule %s: %s", rule.MaxAge, rule.Name, err)
+		}
+	}
+	lookback := maxAge
+	if rule.Lookback != "" {
+		var err error
+		lookback, err = time.ParseDuration(rule.Lookback)
+		if err != nil {
+			return 0.0, fmt.Errorf("Bad lookback '%s' for rule %s: %s", rule.Lookback, rule.Name, err)
+		}
+	}
+
+	now := time.Now()
+	values, newest, ok := pushedSince(name, now.Add(-lookback))
+	if !ok {
+		return 0.0, fmt.Errorf("no values have been pushed for %s", name)
+	}
+	if now.Sub(newest) > maxAge {
+		return 0.0, fmt.Errorf("pushed metric %s is stale, last value was pushed at %s", name, newest.Format(time.RFC3339))
+	}
+
+	method := rule.Reduce
+	if method == "" {
+		method = "last"
+	}
+	return reduce(values, method)
+}
+
+func (b *PushBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
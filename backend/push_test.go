@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPushFutureTimestamp(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name      string
+		timestamp time.Time
+		ok        bool
+	}{
+		{"now", now, true},
+		{"past", now.Add(-time.Hour), true},
+		{"small skew", now.Add(30 * time.Second), true},
+		{"future", now.Add(time.Hour), false},
+		{"milliseconds", time.Unix(now.Unix()*1000, 0), false},
+	}
+	for _, c := range cases {
+		err := Push("test.future."+c.name, 1, c.timestamp)
+		if c.ok && err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestPushEviction(t *testing.T) {
+	pushStore.Lock()
+	saved := pushStore.series
+	pushStore.series = map[string][]pushPoint{}
+	pushStore.Unlock()
+	defer func() {
+		pushStore.Lock()
+		pushStore.series = saved
+		pushStore.Unlock()
+	}()
+
+	old := time.Now().Add(-2 * pushRetention)
+	for i := 0; i < pushMaxMetrics; i++ {
+		timestamp := time.Now()
+		if i == 0 {
+			timestamp = old
+		}
+		if err := Push(fmt.Sprintf("test.eviction.%d", i), 1, timestamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Push("test.eviction.new", 1, time.Time{}); err != nil {
+		t.Fatalf("expected the stale metric to make room, got %s", err)
+	}
+	if _, _, ok := pushedSince("test.eviction.0", old.Add(-time.Second)); ok {
+		t.Error("expected the stale metric to be evicted")
+	}
+	if err := Push("test.eviction.another", 1, time.Time{}); err == nil {
+		t.Error("expected an error once every metric is recent")
+	}
+}
@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+
+	rootcerts "github.com/hashicorp/go-rootcerts"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/redis"
+	"github.com/underarmour/libra/structs"
+)
+
+// RedisConfig is the configuration for a Redis backend
+type RedisConfig struct {
+	Name          string
+	Kind          string
+	Host          string
+	Username      string
+	Password      string
+	DB            int
+	TLS           bool
+	TLSSkipVerify bool
+	CACert        string
+}
+
+// RedisBackend is a metrics backend that reads the length of lists, streams
+// and sorted sets
+type RedisBackend struct {
+	Name       string
+	Config     RedisConfig
+	Connection *redis.Client
+}
+
+// NewRedisBackend will create a new Redis Client
+func NewRedisBackend(name string, config RedisConfig) (*RedisBackend, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLS {
+		serverName, _, err := net.SplitHostPort(config.Host)
+		if err != nil {
+			return nil, fmt.Errorf("bad host '%s', expected host:port: %s", config.Host, err)
+		}
+		tlsConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         serverName,
+			InsecureSkipVerify: config.TLSSkipVerify,
+		}
+		if err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{CAFile: config.CACert}); err != nil {
+			return nil, err
+		}
+	}
+	sess := redis.NewClient(config.Host, config.Username, config.Password, config.DB, tlsConfig)
+
+	backend := &RedisBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = sess
+
+	return backend, nil
+}
+
+// GetValue runs LLEN, XLEN or ZCARD on the rule's key, or returns the number
+// of pending messages of a stream consumer group with XPENDING
+func (b *RedisBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.Key == "" {
+		return 0.0, fmt.Errorf("Missing key inside config{} stanza for rule %s", rule.Name)
+	}
+
+	var args []string
+	switch strings.ToLower(rule.MetricName) {
+	case "llen", "xlen", "zcard":
+		args = []string{strings.ToUpper(rule.MetricName), rule.Key}
+	case "xpending":
+		if rule.ConsumerGroup == "" {
+			return 0.0, fmt.Errorf("Missing consumer_group inside config{} stanza for rule %s", rule.Name)
+		}
+		args = []string{"XPENDING", rule.Key, rule.ConsumerGroup}
+	default:
+		return 0.0, fmt.Errorf("unknown redis metric_name '%s' for rule %s, expected one of llen, xlen, zcard or xpending", rule.MetricName, rule.Name)
+	}
+
+	reply, err := b.Connection.Do(args...)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+
+	// XPENDING replies with [count, smallest id, greatest id, consumers]
+	if items, ok := reply.([]interface{}); ok && len(items) > 0 {
+		reply = items[0]
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0.0, fmt.Errorf("unexpected reply %v to %s", reply, strings.Join(args, " "))
+	}
+	return float64(count), nil
+}
+
+func (b *RedisBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck sends a PING, which also checks the password and db
+func (b *RedisBackend) HealthCheck() error {
+	_, err := b.Connection.Do("PING")
+	return err
+}
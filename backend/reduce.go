@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reduce collapses a set of values into one using the given method. An empty
+// method averages the values. Percentiles are written as pNN, e.g. p95, and
+// last returns the final value, so values should be in time order.
+func reduce(values []float64, method string) (float64, error) {
+	if len(values) == 0 {
+		return 0.0, errors.New("no values to reduce")
+	}
+
+	switch method {
+	case "", "avg", "average", "mean":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values)), nil
+	case "max":
+		max := values[0]
+		for _, v := range values[1:] {
+			max = math.Max(max, v)
+		}
+		return max, nil
+	case "min":
+		min := values[0]
+		for _, v := range values[1:] {
+			min = math.Min(min, v)
+		}
+		return min, nil
+	case "sum":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum, nil
+	case "last":
+		return values[len(values)-1], nil
+	case "median":
+		return percentile(values, 50), nil
+	}
+
+	if strings.HasPrefix(method, "p") {
+		p, err := strconv.ParseFloat(method[1:], 64)
+		if err == nil && p >= 0 && p <= 100 {
+			return percentile(values, p), nil
+		}
+	}
+	return 0.0, fmt.Errorf("unknown reduce method '%s'", method)
+}
+
+// percentile returns the p-th percentile of values, interpolating linearly
+// between the two closest ranks
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// reduceSeries reduces every series over time with timeReduce (default last)
+// and combines the results with seriesReduce (default avg). Series without
+// values are skipped.
+func reduceSeries(series [][]float64, timeReduce, seriesReduce string) (float64, error) {
+	if timeReduce == "" {
+		timeReduce = "last"
+	}
+
+	values := []float64{}
+	for _, points := range series {
+		if len(points) == 0 {
+			continue
+		}
+		value, err := reduce(points, timeReduce)
+		if err != nil {
+			return 0.0, err
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return 0.0, errors.New("no datapoints found for metric")
+	}
+	return reduce(values, seriesReduce)
+}
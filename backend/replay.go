@@ -0,0 +1,222 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/underarmour/libra/structs"
+)
+
+// ReplayConfig is the configuration for a replay backend
+type ReplayConfig struct {
+	Name string
+	Kind string
+	Path string
+	Loop bool
+}
+
+// ReplayBackend steps through a recorded time series, one value per
+// evaluation of a rule
+type ReplayBackend struct {
+	Name   string
+	Config ReplayConfig
+	Series map[string][]float64
+
+	mu        sync.Mutex
+	positions map[string]int
+}
+
+// NewReplayBackend will create a new replay backend and read its file
+func NewReplayBackend(name string, config ReplayConfig) (*ReplayBackend, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("missing path")
+	}
+	series, err := readReplayFile(config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &ReplayBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Series = series
+	backend.positions = map[string]int{}
+
+	return backend, nil
+}
+
+// GetValue returns the next value of the series named by the rule's
+// metric_name, or of the file's only series. Every rule keeps its own
+// position. At the end of the series the last value is repeated, or the
+// series starts over when loop is set.
+func (b *ReplayBackend) GetValue(rule structs.Rule) (float64, error) {
+	values, err := b.series(rule)
+	if err != nil {
+		return 0.0, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := strings.Join([]string{rule.Job, rule.Group, rule.Name, rule.MetricName}, "/")
+	i := b.positions[key]
+	if i >= len(values) {
+		if b.Config.Loop {
+			i = 0
+		} else {
+			i = len(values) - 1
+		}
+	}
+	b.positions[key] = i + 1
+	return values[i], nil
+}
+
+func (b *ReplayBackend) series(rule structs.Rule) ([]float64, error) {
+	if rule.MetricName != "" {
+		values, ok := b.Series[rule.MetricName]
+		if !ok {
+			return nil, fmt.Errorf("no series named %s in %s", rule.MetricName, b.Config.Path)
+		}
+		return values, nil
+	}
+	if len(b.Series) != 1 {
+		return nil, fmt.Errorf("Missing metric_name inside config{} stanza for rule %s, %s has %d series", rule.Name, b.Config.Path, len(b.Series))
+	}
+	for _, values := range b.Series {
+		return values, nil
+	}
+	return nil, nil
+}
+
+// cacheKey includes the job, group and name of the rule, since every rule
+// keeps its own position
+func (b *ReplayBackend) cacheKey(rule structs.Rule) (interface{}, error) {
+	return []interface{}{rule.Name, groupCacheKey(rule)}, nil
+}
+
+func (b *ReplayBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// readReplayFile reads a .json or .csv file into named series. Empty series
+// are an error.
+func readReplayFile(path string) (map[string][]float64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var series map[string][]float64
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		series, err = parseReplayJSON(data)
+	case ".csv":
+		series, err = parseReplayCSV(data)
+	default:
+		return nil, fmt.Errorf("%s must be a .json or .csv file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("problem reading %s: %s", path, err)
+	}
+
+	if len(series) == 0 {
+		return nil, fmt.Errorf("%s has no series", path)
+	}
+	for name, values := range series {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("series %s in %s has no values", name, path)
+		}
+	}
+	return series, nil
+}
+
+// parseReplayJSON reads either a list of values, a list of objects with a
+// value field, or an object of named lists of values
+func parseReplayJSON(data []byte) (map[string][]float64, error) {
+	var named map[string][]float64
+	if err := json.Unmarshal(data, &named); err == nil {
+		return named, nil
+	}
+
+	var points []json.RawMessage
+	if err := json.Unmarshal(data, &points); err != nil {
+		return nil, fmt.Errorf("expected a list of values or an object of named lists: %s", err)
+	}
+	values := make([]float64, 0, len(points))
+	for i, p := range points {
+		var v float64
+		if err := json.Unmarshal(p, &v); err == nil {
+			values = append(values, v)
+			continue
+		}
+		var point struct {
+			Value *float64 `json:"value"`
+		}
+		if err := json.Unmarshal(p, &point); err != nil || point.Value == nil {
+			return nil, fmt.Errorf("point %d is neither a number nor an object with a value", i+1)
+		}
+		values = append(values, *point.Value)
+	}
+	return map[string][]float64{"": values}, nil
+}
+
+// parseReplayCSV reads one column per series. When the first row is not
+// numeric it names the columns; a column named timestamp or time is
+// skipped. Without a header, the last column is the only series.
+func parseReplayCSV(data []byte) (map[string][]float64, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	hasHeader := false
+	for _, field := range header {
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			hasHeader = true
+			break
+		}
+	}
+
+	series := map[string][]float64{}
+	if !hasHeader {
+		column := len(header) - 1
+		for i, row := range rows {
+			v, err := strconv.ParseFloat(row[column], 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s", i+1, err)
+			}
+			series[""] = append(series[""], v)
+		}
+		return series, nil
+	}
+
+	for i, row := range rows[1:] {
+		for column, name := range header {
+			if name == "timestamp" || name == "time" {
+				continue
+			}
+			v, err := strconv.ParseFloat(row[column], 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %s", i+2, name, err)
+			}
+			series[name] = append(series[name], v)
+		}
+	}
+	return series, nil
+}
@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	api "github.com/hashicorp/nomad/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/nomad"
+	"github.com/underarmour/libra/structs"
+)
+
+// SQSConfig is the configuration for an SQS backend
+type SQSConfig struct {
+	Name         string
+	Kind         string
+	Region       string
+	Endpoint     string
+	NomadAddress string
+}
+
+// SQSBackend is a metrics backend that reads queue depth straight from SQS
+type SQSBackend struct {
+	Name       string
+	Config     SQSConfig
+	Connection *sqs.SQS
+	Nomad      *api.Client
+	nomadHTTP  *http.Client
+}
+
+// NewSQSBackend will create a new SQS Client
+func NewSQSBackend(name string, config SQSConfig) (*SQSBackend, error) {
+	sess := newAWSSession(config.Region, config.Endpoint)
+	svc := sqs.New(sess)
+
+	n, nomadHTTP, err := newNomadClient(config.NomadAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &SQSBackend{}
+	backend.Name = name
+	backend.Config = config
+	backend.Connection = svc
+	backend.Nomad = n
+	backend.nomadHTTP = nomadHTTP
+
+	return backend, nil
+}
+
+// GetValue gets the number of visible messages in the rule's queue, optionally
+// including in-flight and delayed messages. With per_instance set, the backlog
+// is divided by the current count of the rule's group.
+func (b *SQSBackend) GetValue(rule structs.Rule) (float64, error) {
+	if rule.Queue == "" {
+		return 0.0, fmt.Errorf("Missing queue inside config{} stanza for rule %s", rule.Name)
+	}
+
+	queueURL, err := b.queueURL(rule.Queue)
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+
+	names := []string{sqs.QueueAttributeNameApproximateNumberOfMessages}
+	if rule.IncludeInFlight {
+		names = append(names, sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible)
+	}
+	if rule.IncludeDelayed {
+		names = append(names, sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed)
+	}
+	s, err := b.Connection.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: aws.StringSlice(names),
+	})
+	if err != nil {
+		log.Println(err)
+		return 0.0, err
+	}
+
+	backlog := 0.0
+	for _, name := range names {
+		attribute, ok := s.Attributes[name]
+		if !ok || attribute == nil {
+			return 0.0, fmt.Errorf("queue %s did not return attribute %s", rule.Queue, name)
+		}
+		value, err := strconv.ParseFloat(*attribute, 64)
+		if err != nil {
+			return 0.0, fmt.Errorf("queue %s returned non-numeric %s %q", rule.Queue, name, *attribute)
+		}
+		backlog += value
+	}
+
+	if !rule.PerInstance {
+		return backlog, nil
+	}
+	count, err := nomad.GroupCount(b.Nomad, rule.Job, rule.Group)
+	if err != nil {
+		return 0.0, fmt.Errorf("problem getting count of %s/%s: %s", rule.Job, rule.Group, err)
+	}
+	// with no instances running the whole backlog is on the next one
+	if count == 0 {
+		return backlog, nil
+	}
+	return backlog / float64(count), nil
+}
+
+// cacheKey includes the job and group when the backlog is divided by their
+// count
+func (b *SQSBackend) cacheKey(rule structs.Rule) (interface{}, error) {
+	if rule.PerInstance {
+		return groupCacheKey(rule), nil
+	}
+	return queryParameters(rule), nil
+}
+
+func (b *SQSBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
+
+// HealthCheck lists the queues the credentials can see
+func (b *SQSBackend) HealthCheck() error {
+	_, err := b.Connection.ListQueues(&sqs.ListQueuesInput{})
+	return err
+}
+
+// queueURL resolves a queue name to its url. Urls are returned as-is.
+func (b *SQSBackend) queueURL(queue string) (string, error) {
+	if strings.Contains(queue, "://") {
+		return queue, nil
+	}
+	s, err := b.Connection.GetQueueUrl(&sqs.GetQueueUrlInput{
+		QueueName: aws.String(queue),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(s.QueueUrl), nil
+}
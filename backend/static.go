@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"github.com/underarmour/libra/structs"
+)
+
+// StaticConfig is the configuration for a static backend
+type StaticConfig struct {
+	Name   string
+	Kind   string
+	Value  float64
+	Values map[string]float64
+}
+
+// StaticBackend returns configured constant values, for trying out rules
+// without a real metrics source
+type StaticBackend struct {
+	Name   string
+	Config StaticConfig
+}
+
+// NewStaticBackend will create a new static backend
+func NewStaticBackend(name string, config StaticConfig) (*StaticBackend, error) {
+	backend := &StaticBackend{}
+	backend.Name = name
+	backend.Config = config
+
+	return backend, nil
+}
+
+// GetValue returns the value configured for the rule's metric_name, or the
+// backend's value when there is none
+func (b *StaticBackend) GetValue(rule structs.Rule) (float64, error) {
+	if value, ok := b.Config.Values[rule.MetricName]; ok {
+		return value, nil
+	}
+	return b.Config.Value, nil
+}
+
+func (b *StaticBackend) Info() *structs.Backend {
+	return &structs.Backend{
+		Kind: b.Config.Kind,
+		Name: b.Name,
+	}
+}
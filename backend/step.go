@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// ValidateSteps checks that every step has an adjustment and that no two
+// steps overlap
+func ValidateSteps(steps map[string]*structs.Step) error {
+	sorted := sortedSteps(steps)
+	for i, s := range sorted {
+		if s.Adjustment == 0 {
+			return fmt.Errorf("step %s needs a non-zero adjustment", s.Name)
+		}
+		lower, upper := stepBounds(s)
+		if lower >= upper {
+			return fmt.Errorf("step %s has a lower_bound of %.2f, which must be below its upper_bound of %.2f", s.Name, lower, upper)
+		}
+		if i > 0 {
+			_, prevUpper := stepBounds(sorted[i-1])
+			if lower < prevUpper {
+				return fmt.Errorf("steps %s and %s overlap", sorted[i-1].Name, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// matchingStep returns the step whose band holds value. Bands include their
+// lower bound but not their upper bound.
+func matchingStep(steps map[string]*structs.Step, value float64) *structs.Step {
+	for _, s := range sortedSteps(steps) {
+		lower, upper := stepBounds(s)
+		if value >= lower && value < upper {
+			return s
+		}
+	}
+	return nil
+}
+
+func stepBounds(s *structs.Step) (float64, float64) {
+	lower, upper := math.Inf(-1), math.Inf(1)
+	if s.LowerBound != nil {
+		lower = *s.LowerBound
+	}
+	if s.UpperBound != nil {
+		upper = *s.UpperBound
+	}
+	return lower, upper
+}
+
+func sortedSteps(steps map[string]*structs.Step) []*structs.Step {
+	sorted := make([]*structs.Step, 0, len(steps))
+	for _, s := range steps {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		li, _ := stepBounds(sorted[i])
+		lj, _ := stepBounds(sorted[j])
+		return li < lj
+	})
+	return sorted
+}
+
+// stepProposal changes the count by the adjustment of the step matching
+// value. The new count is limited to min and max, so a large step near the
+// limits still scales as far as it can.
+func stepProposal(r *structs.Rule, value float64, job, group string, current, min, max int) (int, bool, error) {
+	if err := ValidateSteps(r.Steps); err != nil {
+		log.Errorf("Bad steps for rule %s: %s", r.Name, err)
+		return 0, false, err
+	}
+	step := matchingStep(r.Steps, value)
+	if step == nil {
+		alarming(r, job, group, 0)
+		log.Debugf("Metric %s was %.2f, which is outside of every step. Not scaling", r.Name, value)
+		return 0, false, nil
+	}
+	// Steps that scale the other way do not count toward the alarm
+	if !alarming(r, job, group, direction(step.Adjustment)) {
+		return 0, false, nil
+	}
+
+	desired := current + step.Adjustment
+	if desired > max {
+		desired = max
+	}
+	if desired < min {
+		desired = min
+	}
+	if desired == current {
+		log.Infof("Metric %s was %.2f, in step %s, but %s/%s is already at its limit of %d", r.Name, value, step.Name, job, group, current)
+	} else {
+		log.Infof("Metric %s was %.2f, in step %s. Attempting to change count of %s/%s by %d to %d", r.Name, value, step.Name, job, group, desired-current, desired)
+	}
+	return desired, true, nil
+}
+
+// direction returns 1 for a scale-out adjustment and -1 for a scale-in one
+func direction(adjustment int) int {
+	if adjustment < 0 {
+		return -1
+	}
+	return 1
+}
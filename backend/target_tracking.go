@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/underarmour/libra/structs"
+)
+
+// Scaling policies of a group
+const (
+	PolicyThreshold      = "threshold"
+	PolicyTargetTracking = "target_tracking"
+)
+
+// targetProposal proposes the count that brings the rule's metric to its
+// target_value. It always has an opinion, even when that is the current
+// count.
+func targetProposal(r *structs.Rule, value float64, job, group string, current, min, max int) (int, bool, error) {
+	desired := desiredCount(current, value, r.TargetValue, min, max)
+	if desired == current {
+		log.Debugf("Metric %s was %.2f with target %.2f, keeping %s/%s at %d", r.Name, value, r.TargetValue, job, group, current)
+	} else {
+		log.Infof("Metric %s was %.2f with target %.2f. Attempting to set count of %s/%s from %d to %d", r.Name, value, r.TargetValue, job, group, current, desired)
+	}
+	return desired, true, nil
+}
+
+// desiredCount is ceil(current * value / target), clamped to min and max.
+// An empty group is treated as one instance, so it can scale out.
+func desiredCount(current int, value, target float64, min, max int) int {
+	base := current
+	if base < 1 {
+		base = 1
+	}
+	desired := int(math.Ceil(float64(base) * value / target))
+	if desired < min {
+		desired = min
+	}
+	if desired > max {
+		desired = max
+	}
+	return desired
+}
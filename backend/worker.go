@@ -4,29 +4,40 @@ import (
 	"errors"
 
 	log "github.com/sirupsen/logrus"
-	"github.com/underarmour/libra/nomad"
 	"github.com/underarmour/libra/structs"
 )
 
-// Work actually does the autoscaling for a rule
-func Work(r *structs.Rule, nomadConf *nomad.Config, job, group string, min, max int) error {
+// propose gets the value of a rule and returns the count it wants for the
+// group. ok is false when the rule has no opinion, e.g. when its comparison
+// does not hold.
+func propose(r *structs.Rule, policy, job, group string, current, min, max int) (int, bool, error) {
 	if r.BackendInstance == nil {
 		log.Errorf("No BackendInstance set")
-		return errors.New("no BackendInstance set")
+		return 0, false, errors.New("no BackendInstance set")
 	}
-
-	n, err := nomad.NewClient(*nomadConf)
-	if err != nil {
-		log.Errorf("Failed to create Nomad Client: %s", err)
-		return err
+	if policy == PolicyTargetTracking && r.TargetValue <= 0 {
+		log.Errorf("Rule %s needs a target_value above 0", r.Name)
+		return 0, false, errors.New("target_value must be above 0")
 	}
 
 	value, err := r.BackendInstance.GetValue(*r)
 	if err != nil {
 		log.Errorf("problem getting value for metric %s: %s", r.Name, err)
-		return err
+		return 0, false, err
 	}
 
+	switch {
+	case policy == PolicyTargetTracking:
+		return targetProposal(r, value, job, group, current, min, max)
+	case len(r.Steps) > 0:
+		return stepProposal(r, value, job, group, current, min, max)
+	}
+	return thresholdProposal(r, value, job, group, current, min, max)
+}
+
+// thresholdProposal adds or removes action_value instances when the rule's
+// comparison holds, limited to min and max
+func thresholdProposal(r *structs.Rule, value float64, job, group string, current, min, max int) (int, bool, error) {
 	compValue := r.ComparisonValue
 
 	var change bool
@@ -45,32 +56,84 @@ func Work(r *structs.Rule, nomadConf *nomad.Config, job, group string, min, max
 	case "below_or_equal":
 		change = value <= compValue
 	}
-
+	breach := 0
 	if change {
-		switch r.Action {
-		case "increase_count":
-			count := r.ActionValue
-			log.Infof("Metric %s/%s was %.2f, which is above the threshold %.2f. Attempting to increase count of %s/%s by %d", r.MetricNamespace, r.MetricName, value, r.ComparisonValue, job, group, count)
-			_, _, err := nomad.Scale(n, job, group, count, min, max)
-			if err != nil {
-				log.Errorf("problem scaling nomad job/group %s/%s: %s", job, group, err)
-				return err
-			}
-		case "decrease_count":
-			count := -r.ActionValue
-			log.Infof("Metric %s/%s was %.2f, which is below the threshold %.2f. Attempting to decrease count of %s/%s by %d", r.MetricNamespace, r.MetricName, value, r.ComparisonValue, job, group, -count)
-			evaluation, newCount, err := nomad.Scale(n, job, group, count, min, max)
-			if err != nil {
-				log.Errorf("Problem scaling nomad job/group %s/%s: %s", job, group, err)
-				return err
-			} else {
-				log.Infof("Scaled %s/%s to %d successfully with evaluation ID %s", job, group, newCount, evaluation)
-			}
-		default:
-			log.Errorln("Autoscaling action did not match. Doing nothing...")
+		breach = 1
+	}
+	if !alarming(r, job, group, breach) {
+		return 0, false, nil
+	}
+
+	var count int
+	switch r.Action {
+	case "increase_count":
+		count = r.ActionValue
+		log.Infof("Metric %s/%s was %.2f, which is above the threshold %.2f. Attempting to increase count of %s/%s by %d", r.MetricNamespace, r.MetricName, value, r.ComparisonValue, job, group, count)
+	case "decrease_count":
+		count = -r.ActionValue
+		log.Infof("Metric %s/%s was %.2f, which is below the threshold %.2f. Attempting to decrease count of %s/%s by %d", r.MetricNamespace, r.MetricName, value, r.ComparisonValue, job, group, -count)
+	default:
+		log.Errorln("Autoscaling action did not match. Doing nothing...")
+		return 0, false, nil
+	}
+
+	// At a limit the rule still proposes the current count, so it keeps
+	// other rules of the group from scaling the other way
+	desired := current + count
+	if desired > max {
+		desired = max
+	}
+	if desired < min {
+		desired = min
+	}
+	if desired == current {
+		log.Infof("%s/%s is already at its limit of %d", job, group, current)
+	}
+	return desired, true, nil
+}
+
+// applyCount scales the group from current to desired. When scaling out,
+// desired is first lowered to the count limit of any of the rules.
+func applyCount(n groupScaler, rules []*structs.Rule, job, group string, current, desired, min, max int) error {
+	if desired > current {
+		for _, r := range rules {
+			max = limitMaxCount(r, job, group, max)
+		}
+		if desired > max {
+			desired = max
+		}
+		if desired < current {
+			desired = current
 		}
-	} else {
-		log.Debugln("Not scaling")
+	}
+
+	// Keeping the count still goes through scaleGroup, which remembers it
+	// for the scale-in stabilization window
+	evaluation, newCount, err := scaleGroup(n, job, group, current, desired, min, max)
+	if err != nil {
+		log.Errorf("Problem scaling nomad job/group %s/%s: %s", job, group, err)
+		return err
+	}
+	if evaluation != "" {
+		log.Infof("Scaled %s/%s to %d successfully with evaluation ID %s", job, group, newCount, evaluation)
 	}
 	return nil
 }
+
+// limitMaxCount lowers max to the limit of the rule's backend, if it has one
+func limitMaxCount(r *structs.Rule, job, group string, max int) int {
+	limiter, ok := unwrap(r.BackendInstance).(structs.CountLimiter)
+	if !ok {
+		return max
+	}
+	limit, err := limiter.CountLimit(*r)
+	if err != nil {
+		log.Warnf("problem getting count limit for rule %s, keeping max_count %d: %s", r.Name, max, err)
+		return max
+	}
+	if limit < max {
+		log.Infof("Backend %s limits %s/%s to %d instances", r.Backend, job, group, limit)
+		return limit
+	}
+	return max
+}
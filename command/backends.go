@@ -0,0 +1,110 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+
+	"io/ioutil"
+
+	"github.com/mitchellh/cli"
+	"github.com/underarmour/libra/api"
+)
+
+// BackendsCommand is a Command implementation that lists the configured backends.
+type BackendsCommand struct {
+	Address string
+	Test    bool
+	Ui      cli.Ui
+}
+
+func (c *BackendsCommand) Help() string {
+	helpText := `
+Usage: libra backends [options]
+  List the backends configured on a Libra server and the state of their
+  circuit breakers.
+
+Options:
+  -addr=<addr>  Address of a Libra server
+  -test         Check the connection and credentials of every backend.
+                Exits with 1 when a check fails.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *BackendsCommand) Run(args []string) int {
+	backendsFlags := flag.NewFlagSet("backends", flag.ContinueOnError)
+	backendsFlags.StringVar(&c.Address, "addr", "http://127.0.0.1:8646", "Address of a Libra server")
+	backendsFlags.BoolVar(&c.Test, "test", false, "Check the connection and credentials of every backend")
+	if err := backendsFlags.Parse(args); err != nil {
+		return 1
+	}
+	client, err := api.NewClient(&api.Config{Address: c.Address})
+	if err != nil {
+		log.Errorf("Failed to create Libra HTTP client: %s", err)
+		return 1
+	}
+
+	path := "/backends"
+	if c.Test {
+		path += "?test=true"
+	}
+	resp, err := client.NewRequest(path, "get", nil)
+	if err != nil {
+		c.Ui.Error("Problem listing backends: " + err.Error())
+		return 1
+	} else if resp.StatusCode != 200 {
+		c.Ui.Error("Problem listing backends: " + resp.Status)
+		return 1
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		c.Ui.Error("Problem reading response body: " + err.Error())
+		return 1
+	}
+	var backends []api.BackendResponse
+	if err := json.Unmarshal(respBody, &backends); err != nil {
+		c.Ui.Error("Problem parsing response body: " + err.Error())
+		return 1
+	}
+
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 4, 2, ' ', 0)
+	if c.Test {
+		fmt.Fprintln(tw, "Name\tKind\tBreaker\tHealth\tLatency\tError")
+	} else {
+		fmt.Fprintln(tw, "Name\tKind\tBreaker")
+	}
+	failed := false
+	for _, b := range backends {
+		if !c.Test || b.Health == nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", b.Name, b.Kind, b.Breaker.State)
+			continue
+		}
+		latency := "-"
+		if b.Health.Status != "unsupported" {
+			latency = fmt.Sprintf("%.0fms", b.Health.LatencyMS)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", b.Name, b.Kind, b.Breaker.State, b.Health.Status, latency, b.Health.Error)
+		if b.Health.Status == "error" {
+			failed = true
+		}
+	}
+	tw.Flush()
+	c.Ui.Output(strings.TrimSpace(out.String()))
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func (c *BackendsCommand) Synopsis() string {
+	return "List backends and check their connections"
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"io/ioutil"
+
+	"github.com/mitchellh/cli"
+	"github.com/underarmour/libra/api"
+)
+
+// GroupsCommand is a Command implementation that lists the configured groups.
+type GroupsCommand struct {
+	Address string
+	Ui      cli.Ui
+}
+
+func (c *GroupsCommand) Help() string {
+	helpText := `
+Usage: libra groups [options]
+  List the groups configured on a Libra server with their scaling policy and
+  cooldown state.
+
+Options:
+  -addr=<addr>  Address of a Libra server
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *GroupsCommand) Run(args []string) int {
+	groupsFlags := flag.NewFlagSet("groups", flag.ContinueOnError)
+	groupsFlags.StringVar(&c.Address, "addr", "http://127.0.0.1:8646", "Address of a Libra server")
+	if err := groupsFlags.Parse(args); err != nil {
+		return 1
+	}
+	client, err := api.NewClient(&api.Config{Address: c.Address})
+	if err != nil {
+		log.Errorf("Failed to create Libra HTTP client: %s", err)
+		return 1
+	}
+
+	resp, err := client.NewRequest("/groups", "get", nil)
+	if err != nil {
+		c.Ui.Error("Problem listing groups: " + err.Error())
+		return 1
+	} else if resp.StatusCode != 200 {
+		c.Ui.Error("Problem listing groups: " + resp.Status)
+		return 1
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		c.Ui.Error("Problem reading response body: " + err.Error())
+		return 1
+	}
+	var groups []api.GroupResponse
+	if err := json.Unmarshal(respBody, &groups); err != nil {
+		c.Ui.Error("Problem parsing response body: " + err.Error())
+		return 1
+	}
+
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Job\tGroup\tPolicy\tCount\tScale out\tScale in\tScale-in floor")
+	now := time.Now()
+	for _, g := range groups {
+		floor := "-"
+		if g.Cooldown.ScaleInFloor != nil {
+			floor = fmt.Sprint(*g.Cooldown.ScaleInFloor)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d-%d\t%s\t%s\t%s\n", g.Job, g.Group, g.Policy, g.MinCount, g.MaxCount,
+			cooldownState(g.Cooldown.ScaleOutBlockedTo, now), cooldownState(g.Cooldown.ScaleInBlockedTo, now), floor)
+	}
+	tw.Flush()
+	c.Ui.Output(strings.TrimSpace(out.String()))
+	return 0
+}
+
+func (c *GroupsCommand) Synopsis() string {
+	return "List groups and their cooldowns"
+}
+
+// cooldownState describes a cooldown that ends at until
+func cooldownState(until *time.Time, now time.Time) string {
+	if until == nil || !until.After(now) {
+		return "ready"
+	}
+	// rounded up to the second, so the last second does not show as 0s
+	left := (until.Sub(now) + time.Second - 1) / time.Second * time.Second
+	return "cooldown, " + left.String() + " left"
+}
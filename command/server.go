@@ -3,15 +3,12 @@ package command
 import (
 	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
 	"os"
 	"strings"
 
 	"flag"
 
-	"time"
-
 	"github.com/ant0ine/go-json-rest/rest"
 	"github.com/mitchellh/cli"
 	"github.com/sirupsen/logrus"
@@ -19,7 +16,6 @@ import (
 	"github.com/underarmour/libra/backend"
 	"github.com/underarmour/libra/config"
 	"github.com/underarmour/libra/nomad"
-	"github.com/underarmour/libra/structs"
 	"gopkg.in/robfig/cron.v2"
 )
 
@@ -72,7 +68,9 @@ func (c *ServerCommand) Run(args []string) int {
 		rest.Post("/scale", api.ScaleHandler),
 		rest.Post("/capacity", api.CapacityHandler),
 		rest.Post("/grafana", api.GrafanaHandler),
+		rest.Post("/push", api.PushHandler),
 		rest.Get("/backends", api.BackendsHandler),
+		rest.Get("/groups", api.GroupsHandler),
 		rest.Get("/ping", api.PingHandler),
 		rest.Get("/", api.HomeHandler),
 		rest.Post("/restart", api.RestartHandler),
@@ -142,13 +140,37 @@ func loadRules() (*cron.Cron, []cron.EntryID, error) {
 			logrus.Infof("      min_count = %d", group.MinCount)
 			logrus.Infof("      max_count = %d", group.MaxCount)
 
+			switch group.Policy {
+			case "", backend.PolicyThreshold, backend.PolicyTargetTracking:
+			default:
+				return cr, ids, fmt.Errorf("Unknown policy %s for group %s", group.Policy, group.Name)
+			}
+			if group.Policy != "" {
+				logrus.Infof("      policy = %s", group.Policy)
+			}
+			cooldown, err := backend.NewCooldown(group)
+			if err != nil {
+				return cr, ids, fmt.Errorf("Bad configuration for group %s: %s", group.Name, err)
+			}
+			backend.ConfigureCooldown(job.Name, group.Name, cooldown)
+
+			if err := backend.ValidateArbitration(group.Arbitration); err != nil {
+				return cr, ids, fmt.Errorf("Bad configuration for group %s: %s", group.Name, err)
+			}
+			if group.Arbitration != "" {
+				logrus.Infof("      arbitration = %s", group.Arbitration)
+			}
+
 			for name, rule := range group.Rules {
-				cfID, err := cr.AddFunc(rule.Period, createCronFunc(rule, &config.Nomad, job.Name, group.Name, group.MinCount, group.MaxCount))
-				if err != nil {
-					logrus.Errorf("Problem adding autoscaling rule to cron: %s", err)
-					return cr, ids, err
+				if group.Policy == backend.PolicyTargetTracking && rule.TargetValue <= 0 {
+					return cr, ids, fmt.Errorf("Rule %s needs a target_value above 0 for the target_tracking policy", name)
+				}
+				if err := backend.ValidateEvaluationPeriods(rule); err != nil {
+					return cr, ids, fmt.Errorf("Bad configuration for rule %s: %s", name, err)
+				}
+				if err := backend.ValidateSteps(rule.Steps); err != nil {
+					return cr, ids, fmt.Errorf("Bad steps for rule %s: %s", name, err)
 				}
-				ids = append(ids, cfID)
 				logrus.Infof("  ----> Rule: %s", rule.Name)
 				if backends[rule.Backend] == nil {
 					return cr, ids, fmt.Errorf("Unknown backend: %s (%s)", rule.Backend, name)
@@ -156,15 +178,47 @@ func loadRules() (*cron.Cron, []cron.EntryID, error) {
 
 				rule.BackendInstance = backends[rule.Backend]
 			}
+
+			schedule, err := groupSchedule(group)
+			if err != nil {
+				return cr, ids, err
+			}
+			logrus.Infof("      cron = %s", schedule)
+			cfID, err := cr.AddFunc(schedule, createCronFunc(group, &config.Nomad, job.Name))
+			if err != nil {
+				logrus.Errorf("Problem adding autoscaling group to cron: %s", err)
+				return cr, ids, err
+			}
+			ids = append(ids, cfID)
 		}
 	}
 	return cr, ids, nil
 }
 
-func createCronFunc(rule *structs.Rule, nomadConf *nomad.Config, job, group string, min, max int) func() {
+func createCronFunc(group *nomad.Group, nomadConf *nomad.Config, job string) func() {
 	return func() {
-		n := rand.Intn(10) // offset cron jobs slightly so they don't collide
-		time.Sleep(time.Duration(n) * time.Second)
-		backend.Work(rule, nomadConf, job, group, min, max)
+		backend.WorkGroup(group, nomadConf, job)
+	}
+}
+
+// groupSchedule returns the group's cron, or the cron shared by all its
+// rules. Rules without a cron are checked every minute.
+func groupSchedule(group *nomad.Group) (string, error) {
+	if group.Cron != "" {
+		return group.Cron, nil
+	}
+	schedule := ""
+	for name, rule := range group.Rules {
+		if rule.Period == "" || rule.Period == schedule {
+			continue
+		}
+		if schedule != "" {
+			return "", fmt.Errorf("Rules of group %s have different cron schedules (%s has %s, not %s), set cron on the group", group.Name, name, rule.Period, schedule)
+		}
+		schedule = rule.Period
+	}
+	if schedule == "" {
+		schedule = "* * * * *"
 	}
+	return schedule, nil
 }
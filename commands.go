@@ -15,6 +15,12 @@ func Commands() map[string]cli.CommandFactory {
 		ErrorWriter: os.Stderr,
 	}
 	return map[string]cli.CommandFactory{
+		"backends": func() (cli.Command, error) {
+			return &command.BackendsCommand{Ui: ui}, nil
+		},
+		"groups": func() (cli.Command, error) {
+			return &command.GroupsCommand{Ui: ui}, nil
+		},
 		"ping": func() (cli.Command, error) {
 			return &command.PingCommand{Ui: ui}, nil
 		},
@@ -61,6 +61,20 @@ func NewConfig(path string) (*RootConfig, error) {
 
 			for ruleName, ruleConfig := range groupConfig.Rules {
 				ruleConfig.Name = ruleName
+				if ruleConfig.Job == "" {
+					ruleConfig.Job = jobName
+				}
+				if ruleConfig.Group == "" {
+					ruleConfig.Group = groupName
+				}
+
+				for metricName, metricConfig := range ruleConfig.Metrics {
+					metricConfig.Name = metricName
+				}
+
+				for stepName, stepConfig := range ruleConfig.Steps {
+					stepConfig.Name = stepName
+				}
 			}
 		}
 	}
@@ -0,0 +1,125 @@
+package datadog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultHost is the Datadog API for the US region
+const DefaultHost = "https://api.datadoghq.com"
+
+// Client wraps http.Client so the consumer doesn't have to
+type Client struct {
+	HTTP   *http.Client
+	Host   string
+	APIKey string
+	AppKey string
+}
+
+// QueryResponse is the response of the metrics query API
+type QueryResponse struct {
+	Status string   `json:"status"`
+	Error  string   `json:"error"`
+	Series []Series `json:"series"`
+}
+
+// Series is a single series of a query result
+type Series struct {
+	Metric    string  `json:"metric"`
+	Scope     string  `json:"scope"`
+	Pointlist []Point `json:"pointlist"`
+}
+
+// Point is a [timestamp, value] pair. Value is nil when there is no data.
+type Point []*float64
+
+// Value returns the value of the point and whether it is set
+func (p Point) Value() (float64, bool) {
+	if len(p) < 2 || p[1] == nil {
+		return 0, false
+	}
+	return *p[1], true
+}
+
+// NewClient creates a new Datadog client, including a custom net/http client.
+// host defaults to DefaultHost.
+func NewClient(host, apiKey, appKey string) *Client {
+	if host == "" {
+		host = DefaultHost
+	}
+	return &Client{
+		HTTP: &http.Client{
+			Timeout: time.Second * 10,
+		},
+		Host:   strings.TrimSuffix(host, "/"),
+		APIKey: apiKey,
+		AppKey: appKey,
+	}
+}
+
+// Query makes a call to the /api/v1/query endpoint: https://docs.datadoghq.com/api/latest/metrics/#query-timeseries-points
+func (c *Client) Query(query string, from, to time.Time) (QueryResponse, error) {
+	var data QueryResponse
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("from", strconv.FormatInt(from.Unix(), 10))
+	params.Set("to", strconv.FormatInt(to.Unix(), 10))
+	req, err := http.NewRequest("GET", c.Host+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		log.Errorf("problem creating datadog request: %s", err)
+		return data, err
+	}
+	req.Header.Set("DD-API-KEY", c.APIKey)
+	req.Header.Set("DD-APPLICATION-KEY", c.AppKey)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		log.Errorf("problem getting datadog response: %s", err)
+		return data, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("problem reading datadog response: %s", err)
+		return data, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return data, fmt.Errorf("datadog returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		log.Errorf("problem parsing datadog response: %s", err)
+		return data, err
+	}
+	if data.Status == "error" {
+		return data, fmt.Errorf("datadog query failed: %s", data.Error)
+	}
+	return data, nil
+}
+
+// Validate makes a call to the /api/v1/validate endpoint, which checks the API key
+func (c *Client) Validate() error {
+	req, err := http.NewRequest("GET", c.Host+"/api/v1/validate", nil)
+	if err != nil {
+		log.Errorf("problem creating datadog request: %s", err)
+		return err
+	}
+	req.Header.Set("DD-API-KEY", c.APIKey)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		log.Errorf("problem getting datadog response: %s", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("datadog returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
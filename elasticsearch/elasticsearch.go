@@ -0,0 +1,97 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client wraps http.Client so the consumer doesn't have to
+type Client struct {
+	HTTP     *http.Client
+	Host     string
+	Username string
+	Password string
+}
+
+// NewClient creates a new Elasticsearch client, including a custom net/http client
+func NewClient(url, username, password string) *Client {
+	return &Client{
+		HTTP: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		Host:     url,
+		Username: username,
+		Password: password,
+	}
+}
+
+// Search makes a call to the /<index>/_search endpoint and returns the decoded
+// response: https://www.elastic.co/guide/en/elasticsearch/reference/current/search-search.html
+// It works the same against OpenSearch.
+func (c *Client) Search(index string, body interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", strings.TrimSuffix(c.Host, "/")+"/"+url.PathEscape(index)+"/_search", bytes.NewReader(b))
+	if err != nil {
+		log.Errorf("problem creating elasticsearch request: %s", err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		log.Errorf("problem getting elasticsearch response: %s", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("problem reading elasticsearch response: %s", err)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("elasticsearch returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		log.Errorf("problem parsing elasticsearch response: %s", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// Ping makes a call to the / endpoint, which returns basic cluster info
+func (c *Client) Ping() error {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(c.Host, "/")+"/", nil)
+	if err != nil {
+		log.Errorf("problem creating elasticsearch request: %s", err)
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		log.Errorf("problem getting elasticsearch response: %s", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("elasticsearch returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -2,12 +2,12 @@ package graphite
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 
-	"bytes"
-
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,15 +19,23 @@ type Client struct {
 	Password string
 }
 
+// RenderResponse is a single series returned by the /render endpoint
 type RenderResponse struct {
 	Target     string      `json:"target"`
-	Datapoints [][]float64 `json:"datapoints"`
+	Datapoints []Datapoint `json:"datapoints"`
 }
 
-// type Datapoint struct {
-// 	Value     float64 `json:""`
-// 	Timestamp int64   `json:""`
-// }
+// Datapoint is a [value, timestamp] pair. Value is nil when Graphite has no
+// data for that interval.
+type Datapoint []*float64
+
+// Value returns the value of the datapoint and whether it is set
+func (d Datapoint) Value() (float64, bool) {
+	if len(d) == 0 || d[0] == nil {
+		return 0, false
+	}
+	return *d[0], true
+}
 
 // NewClient creates a new Graphite client, including a custom net/http client
 func NewClient(url, username, password string) *Client {
@@ -42,9 +50,19 @@ func NewClient(url, username, password string) *Client {
 }
 
 // Render makes a call to the Graphite /render endpoint: https://graphite-api.readthedocs.io/en/latest/api.html
-func (c *Client) Render(metric string) (RenderResponse, error) {
-	var data RenderResponse
-	req, err := http.NewRequest("GET", c.Host+"/graphite/render?target="+metric+"&format=json", nil)
+// from and until are optional and passed through as-is, e.g. "-10min".
+func (c *Client) Render(target, from, until string) ([]RenderResponse, error) {
+	var data []RenderResponse
+	params := url.Values{}
+	params.Set("target", target)
+	params.Set("format", "json")
+	if from != "" {
+		params.Set("from", from)
+	}
+	if until != "" {
+		params.Set("until", until)
+	}
+	req, err := http.NewRequest("GET", c.Host+"/graphite/render?"+params.Encode(), nil)
 	if err != nil {
 		log.Errorf("problem creating graphite request: %s", err)
 		return data, err
@@ -58,16 +76,15 @@ func (c *Client) Render(metric string) (RenderResponse, error) {
 	defer resp.Body.Close()
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		log.Errorf("problem reading graphite response: %s", err)
+		return data, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return data, fmt.Errorf("graphite returned %s", resp.Status)
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
 		log.Errorf("problem parsing graphite response: %s", err)
 		return data, err
 	}
-	bFlattened := flattenJSON(b)
-	json.Unmarshal(bFlattened, &data)
 	return data, nil
 }
-
-func flattenJSON(b []byte) []byte {
-	by := bytes.TrimPrefix(b, []byte("["))
-	by = bytes.TrimSuffix(by, []byte("]"))
-	return by
-}
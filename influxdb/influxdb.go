@@ -0,0 +1,260 @@
+package influxdb
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client wraps http.Client so the consumer doesn't have to
+type Client struct {
+	HTTP     *http.Client
+	Host     string
+	Username string
+	Password string
+	Token    string
+}
+
+// Series is a single series of a query result, holding the numeric values of
+// its value column in the order InfluxDB returned them
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+type influxQLResponse struct {
+	Results []struct {
+		Series []struct {
+			Name    string            `json:"name"`
+			Tags    map[string]string `json:"tags"`
+			Columns []string          `json:"columns"`
+			Values  [][]interface{}   `json:"values"`
+		} `json:"series"`
+		Error string `json:"error"`
+	} `json:"results"`
+	Error string `json:"error"`
+}
+
+// NewClient creates a new InfluxDB client, including a custom net/http client
+func NewClient(url, username, password, token string) *Client {
+	return &Client{
+		HTTP: &http.Client{
+			Timeout: time.Second * 10,
+		},
+		Host:     url,
+		Username: username,
+		Password: password,
+		Token:    token,
+	}
+}
+
+// Query runs an InfluxQL query against the /query endpoint: https://docs.influxdata.com/influxdb/v1.8/tools/api/#query-http-endpoint
+// The value column of every series is the first column that is not "time".
+func (c *Client) Query(database, query string) ([]Series, error) {
+	params := url.Values{}
+	params.Set("db", database)
+	params.Set("q", query)
+	params.Set("epoch", "s")
+	req, err := http.NewRequest("GET", c.Host+"/query?"+params.Encode(), nil)
+	if err != nil {
+		log.Errorf("problem creating influxdb request: %s", err)
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	b, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var data influxQLResponse
+	if err := json.Unmarshal(b, &data); err != nil {
+		log.Errorf("problem parsing influxdb response: %s", err)
+		return nil, err
+	}
+	if data.Error != "" {
+		return nil, fmt.Errorf("influxdb query failed: %s", data.Error)
+	}
+
+	series := []Series{}
+	for _, result := range data.Results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("influxdb query failed: %s", result.Error)
+		}
+		for _, s := range result.Series {
+			column := -1
+			for i, name := range s.Columns {
+				if name != "time" {
+					column = i
+					break
+				}
+			}
+			if column == -1 {
+				return nil, fmt.Errorf("series %s has no value column", s.Name)
+			}
+			values := []float64{}
+			for _, row := range s.Values {
+				if column >= len(row) {
+					continue
+				}
+				// null values are skipped, everything else must be numeric
+				switch v := row[column].(type) {
+				case nil:
+				case float64:
+					values = append(values, v)
+				default:
+					return nil, fmt.Errorf("series %s has non-numeric value %v in column %s", s.Name, v, s.Columns[column])
+				}
+			}
+			series = append(series, Series{Name: seriesName(s.Name, s.Tags), Values: values})
+		}
+	}
+	return series, nil
+}
+
+// QueryFlux runs a Flux query against the /api/v2/query endpoint: https://docs.influxdata.com/influxdb/v2.0/api/#operation/PostQuery
+// Every table of the result becomes a series of its _value column.
+func (c *Client) QueryFlux(org, query string) ([]Series, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": query,
+		"type":  "flux",
+	})
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if org != "" {
+		params.Set("org", org)
+	}
+	req, err := http.NewRequest("POST", c.Host+"/api/v2/query?"+params.Encode(), bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("problem creating influxdb request: %s", err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/csv")
+	// InfluxDB 1.8 accepts "username:password" in place of a token
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	} else if c.Username != "" {
+		req.Header.Set("Authorization", "Token "+c.Username+":"+c.Password)
+	}
+
+	b, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return parseFluxCSV(b)
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		log.Errorf("problem getting influxdb response: %s", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("problem reading influxdb response: %s", err)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("influxdb returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// parseFluxCSV reads the _value column of every table in a Flux CSV response.
+// Each table starts with its own header row.
+func parseFluxCSV(b []byte) ([]Series, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+
+	series := []Series{}
+	index := map[string]int{}
+	valueColumn, tableColumn := -1, -1
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("problem parsing influxdb flux response: %s", err)
+		}
+		if len(record) == 0 || strings.HasPrefix(record[0], "#") {
+			continue
+		}
+
+		if isFluxHeader(record) {
+			valueColumn, tableColumn = -1, -1
+			for i, name := range record {
+				switch name {
+				case "_value":
+					valueColumn = i
+				case "table":
+					tableColumn = i
+				}
+			}
+			continue
+		}
+		if valueColumn == -1 || valueColumn >= len(record) {
+			return nil, fmt.Errorf("flux result has no _value column")
+		}
+
+		table := ""
+		if tableColumn != -1 && tableColumn < len(record) {
+			table = record[tableColumn]
+		}
+		i, ok := index[table]
+		if !ok {
+			i = len(series)
+			index[table] = i
+			series = append(series, Series{Name: "table " + table})
+		}
+		if record[valueColumn] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(record[valueColumn], 64)
+		if err != nil {
+			return nil, fmt.Errorf("flux result has non-numeric _value %q", record[valueColumn])
+		}
+		series[i].Values = append(series[i].Values, v)
+	}
+	return series, nil
+}
+
+func isFluxHeader(record []string) bool {
+	for _, name := range record {
+		if name == "result" || name == "_value" {
+			return true
+		}
+	}
+	return false
+}
+
+func seriesName(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+	pairs := []string{}
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,380 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client is a minimal Kafka client that only reads consumer group offsets and
+// partition high watermarks. It opens short-lived connections per call.
+type Client struct {
+	Brokers   []string
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+	ClientID  string
+
+	correlationID int32
+}
+
+// PartitionLag is the lag of a consumer group on one partition
+type PartitionLag struct {
+	Topic         string
+	Partition     int32
+	Committed     int64
+	HighWatermark int64
+	Lag           int64
+}
+
+type broker struct {
+	ID   int32
+	Addr string
+}
+
+type partitionMetadata struct {
+	ID     int32
+	Leader int32
+}
+
+type topicMetadata struct {
+	Name       string
+	Partitions []partitionMetadata
+}
+
+type metadata struct {
+	Brokers map[int32]broker
+	Topics  []topicMetadata
+}
+
+// NewClient creates a new Kafka client. tlsConfig is optional.
+func NewClient(brokers []string, tlsConfig *tls.Config) *Client {
+	return &Client{
+		Brokers:   brokers,
+		TLSConfig: tlsConfig,
+		Timeout:   time.Second * 10,
+		ClientID:  "libra",
+	}
+}
+
+// PartitionCount returns the total number of partitions of the given topics
+func (c *Client) PartitionCount(topics []string) (int, error) {
+	m, err := c.metadata(topics)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, t := range m.Topics {
+		count += len(t.Partitions)
+	}
+	return count, nil
+}
+
+// Ping fetches the list of brokers from the first bootstrap broker that
+// answers
+func (c *Client) Ping() error {
+	_, err := c.metadata([]string{})
+	return err
+}
+
+// ConsumerLag returns the lag of a consumer group on every partition of the
+// given topics. Partitions without a committed offset count from the oldest
+// retained offset.
+func (c *Client) ConsumerLag(group string, topics []string) ([]PartitionLag, error) {
+	m, err := c.metadata(topics)
+	if err != nil {
+		return nil, err
+	}
+
+	committed, err := c.committedOffsets(group, m.Topics)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := c.listOffsets(m, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	var earliest map[string]map[int32]int64
+	lags := []PartitionLag{}
+	for _, t := range m.Topics {
+		for _, p := range t.Partitions {
+			lag := PartitionLag{
+				Topic:         t.Name,
+				Partition:     p.ID,
+				Committed:     committed[t.Name][p.ID],
+				HighWatermark: latest[t.Name][p.ID],
+			}
+			if lag.Committed < 0 {
+				if earliest == nil {
+					earliest, err = c.listOffsets(m, -2)
+					if err != nil {
+						return nil, err
+					}
+				}
+				lag.Committed = earliest[t.Name][p.ID]
+			}
+			lag.Lag = lag.HighWatermark - lag.Committed
+			if lag.Lag < 0 {
+				lag.Lag = 0
+			}
+			lags = append(lags, lag)
+		}
+	}
+	return lags, nil
+}
+
+// metadata fetches brokers and partition leaders from the first bootstrap
+// broker that answers
+func (c *Client) metadata(topics []string) (*metadata, error) {
+	e := &encoder{}
+	e.int32(int32(len(topics)))
+	for _, t := range topics {
+		e.string(t)
+	}
+
+	var lastErr error
+	for _, addr := range c.Brokers {
+		body, err := c.request(addr, apiMetadata, 1, e.buf)
+		if err != nil {
+			log.Warnf("problem getting kafka metadata from %s: %s", addr, err)
+			lastErr = err
+			continue
+		}
+		return parseMetadata(body)
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no kafka brokers configured")
+	}
+	return nil, lastErr
+}
+
+func parseMetadata(body []byte) (*metadata, error) {
+	d := &decoder{buf: body}
+	m := &metadata{Brokers: map[int32]broker{}}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		m.Brokers[id] = broker{ID: id, Addr: net.JoinHostPort(host, strconv.Itoa(int(port)))}
+	}
+	d.int32() // controller id
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		code := d.int16()
+		t := topicMetadata{Name: d.string()}
+		d.int8() // is internal
+		for j, pn := 0, d.arrayLen(); j < pn; j++ {
+			d.int16() // partition error code, leaders are checked when listing offsets
+			p := partitionMetadata{ID: d.int32(), Leader: d.int32()}
+			for k, rn := 0, d.arrayLen(); k < rn; k++ {
+				d.int32() // replicas
+			}
+			for k, in := 0, d.arrayLen(); k < in; k++ {
+				d.int32() // in-sync replicas
+			}
+			t.Partitions = append(t.Partitions, p)
+		}
+		if d.err == nil && code != 0 {
+			return nil, fmt.Errorf("topic %s: %s", t.Name, errorCode(code))
+		}
+		m.Topics = append(m.Topics, t)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return m, nil
+}
+
+// committedOffsets asks the group coordinator for the committed offsets of
+// every partition. Partitions without a commit are returned as -1.
+func (c *Client) committedOffsets(group string, topics []topicMetadata) (map[string]map[int32]int64, error) {
+	coordinator, err := c.coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &encoder{}
+	e.string(group)
+	e.int32(int32(len(topics)))
+	for _, t := range topics {
+		e.string(t.Name)
+		e.int32(int32(len(t.Partitions)))
+		for _, p := range t.Partitions {
+			e.int32(p.ID)
+		}
+	}
+	body, err := c.request(coordinator, apiOffsetFetch, 1, e.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{buf: body}
+	offsets := map[string]map[int32]int64{}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		topic := d.string()
+		offsets[topic] = map[int32]int64{}
+		for j, pn := 0, d.arrayLen(); j < pn; j++ {
+			partition := d.int32()
+			offset := d.int64()
+			d.string() // metadata
+			if code := d.int16(); d.err == nil && code != 0 {
+				return nil, fmt.Errorf("offsets of %s/%d for group %s: %s", topic, partition, group, errorCode(code))
+			}
+			offsets[topic][partition] = offset
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return offsets, nil
+}
+
+// coordinator finds the broker that holds a consumer group's offsets
+func (c *Client) coordinator(group string) (string, error) {
+	e := &encoder{}
+	e.string(group)
+
+	var lastErr error
+	for _, addr := range c.Brokers {
+		body, err := c.request(addr, apiGroupCoordinator, 0, e.buf)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		d := &decoder{buf: body}
+		code := d.int16()
+		d.int32() // node id
+		host := d.string()
+		port := d.int32()
+		if d.err != nil {
+			return "", d.err
+		}
+		if code != 0 {
+			return "", fmt.Errorf("coordinator for group %s: %s", group, errorCode(code))
+		}
+		return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no kafka brokers configured")
+	}
+	return "", lastErr
+}
+
+// listOffsets asks every partition leader for the latest (-1) or earliest
+// (-2) offset of its partitions
+func (c *Client) listOffsets(m *metadata, timestamp int64) (map[string]map[int32]int64, error) {
+	byLeader := map[int32]map[string][]int32{}
+	for _, t := range m.Topics {
+		for _, p := range t.Partitions {
+			if p.Leader < 0 {
+				return nil, fmt.Errorf("partition %s/%d has no leader", t.Name, p.ID)
+			}
+			if byLeader[p.Leader] == nil {
+				byLeader[p.Leader] = map[string][]int32{}
+			}
+			byLeader[p.Leader][t.Name] = append(byLeader[p.Leader][t.Name], p.ID)
+		}
+	}
+
+	offsets := map[string]map[int32]int64{}
+	for leader, topics := range byLeader {
+		b, ok := m.Brokers[leader]
+		if !ok {
+			return nil, fmt.Errorf("unknown kafka broker %d", leader)
+		}
+
+		e := &encoder{}
+		e.int32(-1) // replica id
+		e.int32(int32(len(topics)))
+		for topic, partitions := range topics {
+			e.string(topic)
+			e.int32(int32(len(partitions)))
+			for _, p := range partitions {
+				e.int32(p)
+				e.int64(timestamp)
+			}
+		}
+		body, err := c.request(b.Addr, apiListOffsets, 1, e.buf)
+		if err != nil {
+			return nil, err
+		}
+
+		d := &decoder{buf: body}
+		for i, n := 0, d.arrayLen(); i < n; i++ {
+			topic := d.string()
+			if offsets[topic] == nil {
+				offsets[topic] = map[int32]int64{}
+			}
+			for j, pn := 0, d.arrayLen(); j < pn; j++ {
+				partition := d.int32()
+				code := d.int16()
+				d.int64() // timestamp
+				offset := d.int64()
+				if d.err == nil && code != 0 {
+					return nil, fmt.Errorf("offsets of %s/%d: %s", topic, partition, errorCode(code))
+				}
+				offsets[topic][partition] = offset
+			}
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+	}
+	return offsets, nil
+}
+
+// request sends one request to a broker and returns the response body
+func (c *Client) request(addr string, apiKey, apiVersion int16, body []byte) ([]byte, error) {
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	var conn net.Conn
+	var err error
+	if c.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, c.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	correlationID := atomic.AddInt32(&c.correlationID, 1)
+	header := &encoder{}
+	header.int16(apiKey)
+	header.int16(apiVersion)
+	header.int32(correlationID)
+	header.string(c.ClientID)
+
+	msg := &encoder{}
+	msg.int32(int32(len(header.buf) + len(body)))
+	msg.buf = append(msg.buf, header.buf...)
+	msg.buf = append(msg.buf, body...)
+	if _, err := conn.Write(msg.buf); err != nil {
+		return nil, err
+	}
+
+	var size int32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < 4 {
+		return nil, errShortResponse
+	}
+	resp := make([]byte, size)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	if got := int32(binary.BigEndian.Uint32(resp)); got != correlationID {
+		return nil, fmt.Errorf("kafka correlation id mismatch: sent %d, got %d", correlationID, got)
+	}
+	return resp[4:], nil
+}
@@ -1,6 +1,12 @@
 package nomad
 
+import "net/http"
+
 // Config struct
 type Config struct {
 	Address string `hcl:"address"`
+
+	// HTTPClient replaces the client's default HTTP client, e.g. to bound
+	// its requests with a timeout
+	HTTPClient *http.Client `hcl:"-"`
 }
@@ -8,4 +8,18 @@ type Group struct {
 	MinCount int                      `hcl:"min_count"`
 	MaxCount int                      `hcl:"max_count"`
 	Rules    map[string]*structs.Rule `hcl:"rule"`
+	// Policy is "threshold" (the default), where rules add or remove a fixed
+	// number of instances, or "target_tracking"
+	Policy string `hcl:"policy"`
+	// Cooldowns and the scale-in stabilization window, e.g. "5m"
+	ScaleOutCooldown     string `hcl:"scale_out_cooldown"`
+	ScaleInCooldown      string `hcl:"scale_in_cooldown"`
+	ScaleInStabilization string `hcl:"scale_in_stabilization"`
+	// Arbitration resolves the counts proposed by the rules to one count:
+	// "scale_out_wins" (the default), "largest_change" or
+	// "unanimous_scale_in"
+	Arbitration string `hcl:"arbitration"`
+	// Cron is how often the rules are evaluated. It defaults to the cron of
+	// the rules, which must then all be the same.
+	Cron string `hcl:"cron"`
 }
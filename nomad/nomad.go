@@ -18,6 +18,9 @@ func NewClient(c Config) (*api.Client, error) {
 	} else {
 		nomadDefaultConfig.Address = c.Address
 	}
+	if c.HTTPClient != nil {
+		nomadDefaultConfig.HttpClient = c.HTTPClient
+	}
 
 	client, err := api.NewClient(nomadDefaultConfig)
 	if err != nil {
@@ -33,13 +36,20 @@ func Scale(client *api.Client, jobID, groupID string, scale, min, max int) (stri
 	if err != nil {
 		return "", 0, err
 	}
-	oldCount := *job.TaskGroups[0].Count
+	tg := taskGroup(job, groupID)
+	if tg == nil {
+		return "", 0, errors.New("task group " + groupID + " not found in job " + jobID)
+	}
+	oldCount := *tg.Count
 	newCount := oldCount + scale
 	if newCount < min || newCount > max {
 		return "", oldCount, errors.New("the new group count (" + strconv.Itoa(newCount) + ") is outside of the configured range (" + strconv.Itoa(min) + "-" + strconv.Itoa(max) + ")")
 	}
-	job.TaskGroups[0].Count = &newCount
-	resp, _, _ := client.Jobs().Register(job, &api.WriteOptions{})
+	tg.Count = &newCount
+	resp, _, err := client.Jobs().Register(job, &api.WriteOptions{})
+	if err != nil {
+		return "", oldCount, err
+	}
 	return resp.EvalID, newCount, nil
 }
 
@@ -72,11 +82,62 @@ func SetCapacity(client *api.Client, jobID, groupID string, count, min, max int)
 	if err != nil {
 		return "", 0, err
 	}
-	oldCount := *job.TaskGroups[0].Count
+	tg := taskGroup(job, groupID)
+	if tg == nil {
+		return "", 0, errors.New("task group " + groupID + " not found in job " + jobID)
+	}
+	oldCount := *tg.Count
 	if count < min || count > max {
 		return "", oldCount, errors.New("the desired count (" + strconv.Itoa(count) + ") is outside of the configured range (" + strconv.Itoa(min) + "-" + strconv.Itoa(max) + ")")
 	}
-	job.TaskGroups[0].Count = &count
-	resp, _, _ := client.Jobs().Register(job, &api.WriteOptions{})
+	tg.Count = &count
+	resp, _, err := client.Jobs().Register(job, &api.WriteOptions{})
+	if err != nil {
+		return "", oldCount, err
+	}
 	return resp.EvalID, count, nil
 }
+
+// RunningAllocations returns the running allocations of a task group
+func RunningAllocations(client *api.Client, jobID, group string) ([]*api.Allocation, error) {
+	stubs, _, err := client.Jobs().Allocations(jobID, false, &api.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	allocs := []*api.Allocation{}
+	for _, stub := range stubs {
+		if stub.TaskGroup != group || stub.ClientStatus != "running" {
+			continue
+		}
+		alloc, _, err := client.Allocations().Info(stub.ID, &api.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		allocs = append(allocs, alloc)
+	}
+	return allocs, nil
+}
+
+// GroupCount returns the configured count of a task group
+func GroupCount(client *api.Client, jobID, group string) (int, error) {
+	job, _, err := client.Jobs().Info(jobID, &api.QueryOptions{})
+	if err != nil {
+		return 0, err
+	}
+	tg := taskGroup(job, group)
+	if tg == nil || tg.Count == nil {
+		return 0, errors.New("task group " + group + " not found in job " + jobID)
+	}
+	return *tg.Count, nil
+}
+
+// taskGroup returns the task group with the given name, or nil when the job
+// has none with that name
+func taskGroup(job *api.Job, name string) *api.TaskGroup {
+	for _, tg := range job.TaskGroups {
+		if tg.Name != nil && *tg.Name == name {
+			return tg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client wraps http.Client so the consumer doesn't have to
+type Client struct {
+	HTTP        *http.Client
+	Host        string
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// QueryResponse is the envelope returned by the Prometheus HTTP API
+type QueryResponse struct {
+	Status    string    `json:"status"`
+	Data      QueryData `json:"data"`
+	ErrorType string    `json:"errorType"`
+	Error     string    `json:"error"`
+}
+
+// QueryData holds the result of an instant query. Result is decoded lazily
+// because its shape depends on ResultType.
+type QueryData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+// Sample is a single series of an instant vector
+type Sample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// NewClient creates a new Prometheus client, including a custom net/http client
+func NewClient(url, username, password, bearerToken string) *Client {
+	return &Client{
+		HTTP: &http.Client{
+			Timeout: time.Second * 10,
+		},
+		Host:        url,
+		Username:    username,
+		Password:    password,
+		BearerToken: bearerToken,
+	}
+}
+
+// Query makes a call to the Prometheus /api/v1/query endpoint: https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
+func (c *Client) Query(query string) (QueryResponse, error) {
+	var data QueryResponse
+	params := url.Values{}
+	params.Set("query", query)
+	req, err := http.NewRequest("GET", c.Host+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		log.Errorf("problem creating prometheus request: %s", err)
+		return data, err
+	}
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		log.Errorf("problem getting prometheus response: %s", err)
+		return data, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("problem reading prometheus response: %s", err)
+		return data, err
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return data, fmt.Errorf("prometheus returned %s", resp.Status)
+		}
+		log.Errorf("problem parsing prometheus response: %s", err)
+		return data, err
+	}
+	if data.Status != "success" {
+		return data, fmt.Errorf("prometheus query failed (%s): %s", data.ErrorType, data.Error)
+	}
+	return data, nil
+}
+
+// Value reduces the result of an instant query to a single float. Scalars are
+// returned as-is, vectors must contain exactly one series.
+func (r QueryResponse) Value() (float64, error) {
+	switch r.Data.ResultType {
+	case "scalar":
+		var pair []interface{}
+		if err := json.Unmarshal(r.Data.Result, &pair); err != nil {
+			return 0.0, err
+		}
+		return parseValue(pair)
+	case "vector":
+		var samples []Sample
+		if err := json.Unmarshal(r.Data.Result, &samples); err != nil {
+			return 0.0, err
+		}
+		if len(samples) == 0 {
+			return 0.0, errors.New("query returned no series")
+		}
+		if len(samples) > 1 {
+			return 0.0, fmt.Errorf("query returned %d series, expected exactly one; aggregate it with sum(), avg() or max()", len(samples))
+		}
+		return parseValue(samples[0].Value)
+	default:
+		return 0.0, fmt.Errorf("unsupported result type '%s', expected a scalar or an instant vector", r.Data.ResultType)
+	}
+}
+
+// parseValue converts a [ <unix_time>, "<value>" ] pair into a float
+func parseValue(pair []interface{}) (float64, error) {
+	if len(pair) != 2 {
+		return 0.0, fmt.Errorf("malformed sample value %v", pair)
+	}
+	s, ok := pair[1].(string)
+	if !ok {
+		return 0.0, fmt.Errorf("malformed sample value %v", pair)
+	}
+	return strconv.ParseFloat(s, 64)
+}
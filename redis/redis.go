@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client is a minimal Redis client that opens a connection per command, which
+// is plenty for a handful of reads per cron tick. It speaks RESP: https://redis.io/topics/protocol
+type Client struct {
+	Address   string
+	Username  string
+	Password  string
+	DB        int
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+}
+
+// Error is an error reply from Redis
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// NewClient creates a new Redis client. tlsConfig is optional.
+func NewClient(address, username, password string, db int, tlsConfig *tls.Config) *Client {
+	return &Client{
+		Address:   address,
+		Username:  username,
+		Password:  password,
+		DB:        db,
+		TLSConfig: tlsConfig,
+		Timeout:   time.Second * 10,
+	}
+}
+
+// Do connects, authenticates, selects the database and runs a single command.
+// Replies are returned as int64, string, []interface{} or nil.
+func (c *Client) Do(args ...string) (interface{}, error) {
+	conn, err := c.dial()
+	if err != nil {
+		log.Errorf("problem connecting to redis: %s", err)
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+	r := bufio.NewReader(conn)
+
+	if c.Password != "" {
+		auth := []string{"AUTH", c.Password}
+		if c.Username != "" {
+			auth = []string{"AUTH", c.Username, c.Password}
+		}
+		if _, err := roundTrip(conn, r, auth); err != nil {
+			return nil, fmt.Errorf("redis AUTH failed: %s", err)
+		}
+	}
+	if c.DB != 0 {
+		if _, err := roundTrip(conn, r, []string{"SELECT", strconv.Itoa(c.DB)}); err != nil {
+			return nil, fmt.Errorf("redis SELECT %d failed: %s", c.DB, err)
+		}
+	}
+	return roundTrip(conn, r, args)
+}
+
+func (c *Client) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	if c.TLSConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", c.Address, c.TLSConfig)
+	}
+	return dialer.Dial("tcp", c.Address)
+}
+
+func roundTrip(w io.Writer, r *bufio.Reader, args []string) (interface{}, error) {
+	if err := writeCommand(w, args); err != nil {
+		return nil, err
+	}
+	return readReply(r)
+}
+
+// writeCommand sends a command as an array of bulk strings
+func writeCommand(w io.Writer, args []string) error {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed redis reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i], err = readReply(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, errors.New("unknown redis reply type " + string(kind))
+	}
+}
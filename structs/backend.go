@@ -6,13 +6,64 @@ type Backender interface {
 	GetValue(rule Rule) (float64, error)
 }
 
+// CountLimiter is implemented by backends that know the most instances a
+// group can make use of, e.g. the partition count of a Kafka topic. The
+// limit lowers max_count of the group.
+type CountLimiter interface {
+	CountLimit(rule Rule) (int, error)
+}
+
+// HealthChecker is implemented by backends that can check their connection
+// and credentials without a rule, e.g. with a sample query
+type HealthChecker interface {
+	HealthCheck() error
+}
+
 // Backend struct
 type Backend struct {
 	Name   string `mapstructure:"name"`
 	Kind   string `mapstructure:"kind"`
 	Region string `mapstructure:"region"`
+	// How long values are cached, e.g. "30s". Empty disables the cache.
+	CacheTTL string `mapstructure:"cache_ttl" hcl:"cache_ttl"`
+	// Timeouts, retries and circuit breaking of every request
+	Timeout          string `mapstructure:"timeout"`
+	Retries          int    `mapstructure:"retries"`
+	RetryBackoff     string `mapstructure:"retry_backoff" hcl:"retry_backoff"`
+	BreakerThreshold int    `mapstructure:"breaker_threshold" hcl:"breaker_threshold"`
+	BreakerCooldown  string `mapstructure:"breaker_cooldown" hcl:"breaker_cooldown"`
+	// CloudWatch and SQS
+	Endpoint string `mapstructure:"endpoint"`
 	// Graphite-specific
 	Host     string `mapstructure:"host"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
+	// Prometheus and InfluxDB
+	BearerToken string `mapstructure:"bearer_token" hcl:"bearer_token"`
+	// InfluxDB-specific
+	Database string `mapstructure:"database"`
+	Org      string `mapstructure:"org"`
+	Bucket   string `mapstructure:"bucket"`
+	// HTTP-specific
+	Headers map[string]string `mapstructure:"headers"`
+	// Redis-specific
+	DB int `mapstructure:"db"`
+	// Consul-specific
+	Datacenter string `mapstructure:"datacenter"`
+	Token      string `mapstructure:"token"`
+	// Datadog-specific
+	APIKey string `mapstructure:"api_key" hcl:"api_key"`
+	AppKey string `mapstructure:"app_key" hcl:"app_key"`
+	// Static-specific
+	Value  float64            `mapstructure:"value"`
+	Values map[string]float64 `mapstructure:"values"`
+	// Replay-specific
+	Path string `mapstructure:"path"`
+	Loop bool   `mapstructure:"loop"`
+	// TLS settings
+	TLS           bool   `mapstructure:"tls"`
+	TLSSkipVerify bool   `mapstructure:"tls_skip_verify" hcl:"tls_skip_verify"`
+	CACert        string `mapstructure:"ca_cert" hcl:"ca_cert"`
+	ClientCert    string `mapstructure:"client_cert" hcl:"client_cert"`
+	ClientKey     string `mapstructure:"client_key" hcl:"client_key"`
 }
@@ -3,15 +3,63 @@ package structs
 // Rule struct
 type Rule struct {
 	Name            string
+	Job             string `hcl:"job"`
+	Group           string `hcl:"group"`
 	Backend         string `hcl:"backend"`
 	BackendInstance Backender
-	Comparison      string  `hcl:"comparison"`
-	ComparisonValue float64 `hcl:"comparison_value,float"`
-	Action          string  `hcl:"action"`
-	ActionValue     int     `hcl:"action_value,int"`
-	MetricName      string  `hcl:"metric_name"`
-	MetricNamespace string  `hcl:"metric_namespace"`
-	DimensionName   string  `hcl:"dimension_name"`
-	DimensionValue  string  `hcl:"dimension_value"`
-	Period          string  `hcl:"cron"`
+	Comparison      string            `hcl:"comparison"`
+	ComparisonValue float64           `hcl:"comparison_value,float"`
+	TargetValue     float64           `hcl:"target_value,float"`
+	Action          string            `hcl:"action"`
+	ActionValue     int               `hcl:"action_value,int"`
+	Steps           map[string]*Step  `hcl:"step"`
+	MetricName      string            `hcl:"metric_name"`
+	MetricNamespace string            `hcl:"metric_namespace"`
+	DimensionName   string            `hcl:"dimension_name"`
+	DimensionValue  string            `hcl:"dimension_value"`
+	Dimensions      map[string]string `hcl:"dimensions"`
+	Statistic       string            `hcl:"statistic"`
+	MetricPeriod    int               `hcl:"period"`
+	Lookback        string            `hcl:"lookback"`
+	Expression      string            `hcl:"expression"`
+	Metrics         map[string]*Rule  `hcl:"metric"`
+	Period          string            `hcl:"cron"`
+	Query           string            `hcl:"query"`
+	QueryLanguage   string            `hcl:"query_language"`
+	Reduce          string            `hcl:"reduce"`
+	SeriesReduce    string            `hcl:"series_reduce"`
+	From            string            `hcl:"from"`
+	Until           string            `hcl:"until"`
+	URL             string            `hcl:"url"`
+	Method          string            `hcl:"method"`
+	Headers         map[string]string `hcl:"headers"`
+	Body            string            `hcl:"body"`
+	JSONPath        string            `hcl:"json_path"`
+	Command         []string          `hcl:"command"`
+	Timeout         string            `hcl:"timeout"`
+	Queue           string            `hcl:"queue"`
+	IncludeInFlight bool              `hcl:"include_in_flight"`
+	IncludeDelayed  bool              `hcl:"include_delayed"`
+	PerInstance     bool              `hcl:"per_instance"`
+	Key             string            `hcl:"key"`
+	ConsumerGroup   string            `hcl:"consumer_group"`
+	Topic           string            `hcl:"topic"`
+	Index           string            `hcl:"index"`
+	TimeField       string            `hcl:"time_field"`
+	Service         string            `hcl:"service"`
+	Tag             string            `hcl:"tag"`
+	MaxAge          string            `hcl:"max_age"`
+	// Act only when the rule held in DatapointsToAlarm of the last
+	// EvaluationPeriods evaluations
+	EvaluationPeriods int `hcl:"evaluation_periods"`
+	DatapointsToAlarm int `hcl:"datapoints_to_alarm"`
+}
+
+// Step is a band of metric values and the change in count when the metric
+// falls inside it. A missing bound is unbounded.
+type Step struct {
+	Name       string
+	LowerBound *float64 `hcl:"lower_bound"`
+	UpperBound *float64 `hcl:"upper_bound"`
+	Adjustment int      `hcl:"adjustment"`
 }